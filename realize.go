@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -17,7 +19,7 @@ var r realize.Realize
 
 // Realize cli commands
 func main() {
-	r.Sync = make(chan string)
+	r.Bus = realize.NewBus()
 	app := &cli.App{
 		Name:        strings.Title(realize.RPrefix),
 		Version:     realize.RVersion,
@@ -78,6 +80,18 @@ func main() {
 				},
 				Action: remove,
 			},
+			{
+				Name:        "validate",
+				Category:    "Configuration",
+				Description: "Check an existing config.",
+				Action: func(c *cli.Context) error {
+					if err := validate(); err != nil {
+						return err
+					}
+					log.Println(r.Prefix(realize.Green.Bold(realize.RFile + " is valid")))
+					return nil
+				},
+			},
 			{
 				Name:        "clean",
 				Category:    "Configuration",
@@ -108,6 +122,22 @@ func version() {
 	log.Println(r.Prefix(realize.Green.Bold(realize.RVersion)))
 }
 
+// Validate the config file and print its problems
+func validate() error {
+	content, err := ioutil.ReadFile(realize.RFile)
+	if err != nil {
+		return err
+	}
+	problems := realize.Validate(content)
+	for _, p := range problems {
+		log.Println(r.Prefix(realize.Red.Regular(realize.RFile + ": " + p.Error())))
+	}
+	if len(problems) > 0 {
+		return errors.New(strconv.Itoa(len(problems)) + " problems in " + realize.RFile)
+	}
+	return nil
+}
+
 // Clean remove realize file
 func clean() (err error) {
 	if err := r.Settings.Remove(realize.RFile); err != nil {
@@ -129,7 +159,7 @@ func add(c *cli.Context) (err error) {
 	r.Schema.Add(r.Schema.New(c))
 	if len(r.Schema.Projects) > projects {
 		// update config
-		err = r.Settings.Write(r)
+		err = r.Settings.Write(&r)
 		if err != nil {
 			return err
 		}
@@ -1099,7 +1129,7 @@ func setup(c *cli.Context) (err error) {
 		},
 	})
 	// create config
-	err = r.Settings.Write(r)
+	err = r.Settings.Write(&r)
 	if err != nil {
 		return err
 	}
@@ -1120,11 +1150,20 @@ func start(c *cli.Context) (err error) {
 
 	// check no-config and read
 	if !c.Bool("no-config") {
+		// check the config of all the projects before filtering them
+		if _, err := os.Stat(realize.RFile); err == nil {
+			if err = validate(); err != nil {
+				return err
+			}
+		}
 		// read a config if exist
-		r.Settings.Read(&r)
+		config := r.Settings.Read(&r) == nil
 		if c.String("name") != "" {
 			// filter by name flag if exist
 			r.Schema.Projects = r.Schema.Filter("Name", c.String("name"))
+		} else {
+			// apply the changes of the config file while running
+			r.WatchConfig = config
 		}
 		// increase file limit
 		if r.Settings.FileLimit != 0 {
@@ -1142,7 +1181,7 @@ func start(c *cli.Context) (err error) {
 		r.Schema.Add(project)
 		// save config
 		if !c.Bool("no-config") {
-			err = r.Settings.Write(r)
+			err = r.Settings.Write(&r)
 			if err != nil {
 				return err
 			}
@@ -1177,7 +1216,7 @@ func remove(c *cli.Context) (err error) {
 			return err
 		}
 		// update config
-		err = r.Settings.Write(r)
+		err = r.Settings.Write(&r)
 		if err != nil {
 			return err
 		}
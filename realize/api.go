@@ -0,0 +1,151 @@
+package realize
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// APIPrefix is the base path of the versioned api
+const APIPrefix = "/api/v1"
+
+// default and max size of a buffer page
+const (
+	PageSize    = 50
+	MaxPageSize = 500
+)
+
+// BufferPage is a page of a project buffer
+type BufferPage struct {
+	Stream   string      `json:"stream"`
+	Page     int         `json:"page"`
+	Size     int         `json:"size"`
+	Total    int         `json:"total"`
+	Overflow uint64      `json:"overflow"`
+	Items    interface{} `json:"items"`
+}
+
+// apiError is the body of a failed api request
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// Api registers the api routes
+func (s *Server) api(e *echo.Echo) {
+	g := e.Group(APIPrefix)
+	g.GET("/projects", s.list)
+	g.GET("/projects/:name", s.status)
+	g.GET("/projects/:name/buffer/:stream", s.buffer)
+	g.POST("/projects/:name/rebuild", s.control(ControlRebuild))
+	g.POST("/projects/:name/stop", s.control(ControlStop))
+	g.POST("/projects/:name/start", s.control(ControlStart))
+}
+
+// project returns a project by its name
+func (s *Server) project(c echo.Context) (*Project, error) {
+	name := c.Param("name")
+	for _, p := range s.Parent.projects() {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, c.JSON(http.StatusNotFound, apiError{"project not found"})
+}
+
+// List the status of all projects
+func (s *Server) list(c echo.Context) error {
+	result := []ProjectStatus{}
+	for _, p := range s.Parent.projects() {
+		result = append(result, p.Status())
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Status of a project
+func (s *Server) status(c echo.Context) error {
+	p, err := s.project(c)
+	if p == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, p.Status())
+}
+
+// Control sends a command to the watcher of a project
+func (s *Server) control(command string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		p, err := s.project(c)
+		if p == nil {
+			return err
+		}
+		if p.control == nil {
+			return c.JSON(http.StatusConflict, apiError{"project isn't running"})
+		}
+		select {
+		case p.control <- command:
+			return c.JSON(http.StatusAccepted, p.Status())
+		case <-time.After(time.Second):
+			return c.JSON(http.StatusServiceUnavailable, apiError{"project is busy"})
+		}
+	}
+}
+
+// Buffer returns a page of a project buffer, the newest entries are the last ones
+func (s *Server) buffer(c echo.Context) error {
+	p, err := s.project(c)
+	if p == nil {
+		return err
+	}
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	if page < 1 {
+		page = 1
+	}
+	size, _ := strconv.Atoi(c.QueryParam("size"))
+	if size < 1 {
+		size = PageSize
+	}
+	if size > MaxPageSize {
+		size = MaxPageSize
+	}
+	result := BufferPage{Stream: c.Param("stream"), Page: page, Size: size}
+	switch result.Stream {
+	case "out", "log", "error":
+		var ring *Ring
+		switch result.Stream {
+		case "out":
+			ring = p.Buffer.StdOut
+		case "log":
+			ring = p.Buffer.StdLog
+		case "error":
+			ring = p.Buffer.StdErr
+		}
+		list := ring.Items()
+		from, to := bounds(len(list), page, size)
+		result.Total = len(list)
+		result.Overflow = ring.Dropped()
+		result.Items = append([]BufferOut{}, list[from:to]...)
+	case "test":
+		list := p.Buffer.StdTest.Items()
+		from, to := bounds(len(list), page, size)
+		result.Total = len(list)
+		result.Overflow = p.Buffer.StdTest.Dropped()
+		result.Items = append([]TestResult{}, list[from:to]...)
+	default:
+		return c.JSON(http.StatusNotFound, apiError{"stream not found"})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// bounds returns the indexes of a page in a list
+func bounds(total, page, size int) (from int, to int) {
+	from = (page - 1) * size
+	if from > total {
+		from = total
+	}
+	to = from + size
+	if to > total {
+		to = total
+	}
+	return
+}
@@ -0,0 +1,90 @@
+package realize
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+func request(e *echo.Echo, method string, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestServer_Api(t *testing.T) {
+	r := Realize{}
+	r.Projects = []Project{
+		{Name: "app", Path: "app", state: &state{}, control: make(chan string, 1)},
+		{Name: "idle", Path: "idle"},
+	}
+	r.Projects[0].Buffer.StdLog = NewRing(Retention{Entries: 100})
+	for i := 0; i < 120; i++ {
+		r.Projects[0].Buffer.StdLog.Push(BufferOut{Text: "log"})
+	}
+	r.Projects[0].state.set(StateRunning)
+	r.Projects[0].state.built(time.Now(), Response{Name: "Install"})
+	s := Server{Parent: &r}
+	e := echo.New()
+	s.api(e)
+
+	rec := request(e, http.MethodGet, APIPrefix+"/projects")
+	var list []ProjectStatus
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &list) != nil || len(list) != 2 {
+		t.Fatal("Unexpected projects list", rec.Code, rec.Body.String())
+	}
+	if list[0].State != StateRunning || list[0].LastBuild == nil || !list[0].LastBuild.Success {
+		t.Error("Unexpected status", list[0])
+	}
+	if list[1].State != StateIdle {
+		t.Error("Expected idle project", list[1])
+	}
+
+	if rec := request(e, http.MethodGet, APIPrefix+"/projects/missing"); rec.Code != http.StatusNotFound {
+		t.Error("Expected not found instead", rec.Code)
+	}
+
+	rec = request(e, http.MethodGet, APIPrefix+"/projects/app/buffer/log?page=2&size=60")
+	var page struct {
+		Total    int         `json:"total"`
+		Overflow uint64      `json:"overflow"`
+		Items    []BufferOut `json:"items"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &page) != nil {
+		t.Fatal("Unexpected buffer page", rec.Code, rec.Body.String())
+	}
+	if page.Total != 100 || page.Overflow != 20 || len(page.Items) != 40 {
+		t.Error("Unexpected page", page.Total, page.Overflow, len(page.Items))
+	}
+	if rec := request(e, http.MethodGet, APIPrefix+"/projects/app/buffer/unknown"); rec.Code != http.StatusNotFound {
+		t.Error("Expected not found instead", rec.Code)
+	}
+
+	if rec := request(e, http.MethodPost, APIPrefix+"/projects/app/rebuild"); rec.Code != http.StatusAccepted {
+		t.Error("Expected accepted instead", rec.Code)
+	}
+	if command := <-r.Projects[0].control; command != ControlRebuild {
+		t.Error("Unexpected command", command)
+	}
+	if rec := request(e, http.MethodPost, APIPrefix+"/projects/idle/stop"); rec.Code != http.StatusConflict {
+		t.Error("Expected conflict instead", rec.Code)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	data := [][5]int{
+		{10, 1, 5, 0, 5},
+		{10, 2, 5, 5, 10},
+		{10, 3, 5, 10, 10},
+		{7, 2, 5, 5, 7},
+	}
+	for _, v := range data {
+		if from, to := bounds(v[0], v[1], v[2]); from != v[3] || to != v[4] {
+			t.Error("Unexpected bounds", v, from, to)
+		}
+	}
+}
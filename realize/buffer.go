@@ -0,0 +1,256 @@
+package realize
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// BufferEntries is the default max number of entries of a buffer stream
+const BufferEntries = 1000
+
+// Retention limits of a buffer stream, zero values are unlimited
+type Retention struct {
+	Entries int           `yaml:"entries,omitempty" json:"entries,omitempty"`
+	Bytes   int           `yaml:"bytes,omitempty" json:"bytes,omitempty"`
+	Age     time.Duration `yaml:"age,omitempty" json:"age,omitempty"`
+}
+
+// BufferSettings defines the retention of each buffer stream
+type BufferSettings struct {
+	Out   Retention `yaml:"out,omitempty" json:"out,omitempty"`
+	Log   Retention `yaml:"log,omitempty" json:"log,omitempty"`
+	Error Retention `yaml:"error,omitempty" json:"error,omitempty"`
+	Test  Retention `yaml:"test,omitempty" json:"test,omitempty"`
+}
+
+// entry is an item of a ring, the size and the time are used by the retention limits
+type entry interface {
+	size() int
+	stamp() time.Time
+}
+
+// Ring is a bounded buffer of outputs, the oldest entries are dropped first
+type Ring struct {
+	mu      sync.RWMutex
+	limit   Retention
+	items   []entry
+	head    int
+	count   int
+	bytes   int
+	dropped uint64
+}
+
+// NewRing returns an empty ring, limited to BufferEntries if neither entries nor bytes are limited
+func NewRing(limit Retention) *Ring {
+	if limit.Entries <= 0 && limit.Bytes <= 0 {
+		limit.Entries = BufferEntries
+	}
+	return &Ring{limit: limit}
+}
+
+// newBuffer returns the rings of each stream
+func newBuffer(s BufferSettings) Buffer {
+	return Buffer{
+		StdOut:  NewRing(s.Out),
+		StdLog:  NewRing(s.Log),
+		StdErr:  NewRing(s.Error),
+		StdTest: NewTestRing(s.Test),
+	}
+}
+
+// Push a new entry, dropping the oldest ones over the limits
+func (r *Ring) Push(o BufferOut) {
+	if o.Time.IsZero() {
+		o.Time = time.Now()
+	}
+	r.push(o)
+}
+
+// Items returns a copy of the entries, the newest are the last ones
+func (r *Ring) Items() []BufferOut {
+	if r == nil {
+		return nil
+	}
+	list := r.entries()
+	result := make([]BufferOut, len(list))
+	for i, e := range list {
+		result[i] = e.(BufferOut)
+	}
+	return result
+}
+
+// Dropped returns the number of entries removed by the retention limits
+func (r *Ring) Dropped() uint64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.dropped
+}
+
+// MarshalJSON encodes the entries as a list
+func (r *Ring) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Items())
+}
+
+// push entries, dropping the oldest ones over the limits
+func (r *Ring) push(list ...entry) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range list {
+		if r.limit.Entries > 0 && r.count == r.limit.Entries {
+			r.shift()
+		}
+		if r.count == len(r.items) {
+			r.grow()
+		}
+		r.items[(r.head+r.count)%len(r.items)] = e
+		r.count++
+		r.bytes += e.size()
+		r.trim(time.Now())
+	}
+}
+
+// entries returns a copy of the entries after the age limit
+func (r *Ring) entries() []entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trim(time.Now())
+	result := make([]entry, r.count)
+	for i := range result {
+		result[i] = r.items[(r.head+i)%len(r.items)]
+	}
+	return result
+}
+
+// grow the ring, never over the max number of entries
+func (r *Ring) grow() {
+	size := len(r.items) * 2
+	if size == 0 {
+		size = 16
+	}
+	if r.limit.Entries > 0 && size > r.limit.Entries {
+		size = r.limit.Entries
+	}
+	items := make([]entry, size)
+	for i := 0; i < r.count; i++ {
+		items[i] = r.items[(r.head+i)%len(r.items)]
+	}
+	r.items, r.head = items, 0
+}
+
+// shift drops the oldest entry
+func (r *Ring) shift() {
+	r.bytes -= r.items[r.head].size()
+	r.items[r.head] = nil
+	r.head = (r.head + 1) % len(r.items)
+	r.count--
+	r.dropped++
+}
+
+// trim drops the entries over the bytes and the age limits, the newest entry is kept over the bytes limit
+func (r *Ring) trim(now time.Time) {
+	for r.limit.Bytes > 0 && r.count > 1 && r.bytes > r.limit.Bytes {
+		r.shift()
+	}
+	for r.limit.Age > 0 && r.count > 0 && now.Sub(r.items[r.head].stamp()) > r.limit.Age {
+		r.shift()
+	}
+}
+
+// size is the approximate memory used by the texts of an entry
+func (o BufferOut) size() int {
+	size := len(o.Text) + len(o.Path) + len(o.Type) + len(o.Stream)
+	for _, e := range o.Errors {
+		size += len(e)
+	}
+	return size
+}
+
+func (o BufferOut) stamp() time.Time {
+	return o.Time
+}
+
+// TestRing is a ring of test results
+type TestRing struct {
+	ring *Ring
+}
+
+// NewTestRing returns an empty ring of test results, with the same defaults of NewRing
+func NewTestRing(limit Retention) *TestRing {
+	return &TestRing{ring: NewRing(limit)}
+}
+
+// Push new results, dropping the oldest ones over the limits
+func (r *TestRing) Push(results ...TestResult) {
+	if r == nil {
+		return
+	}
+	list := make([]entry, len(results))
+	for i, t := range results {
+		if t.Time.IsZero() {
+			t.Time = time.Now()
+		}
+		list[i] = t
+	}
+	r.ring.push(list...)
+}
+
+// Items returns a copy of the results, the newest are the last ones
+func (r *TestRing) Items() []TestResult {
+	if r == nil {
+		return nil
+	}
+	list := r.ring.entries()
+	result := make([]TestResult, len(list))
+	for i, e := range list {
+		result[i] = e.(TestResult)
+	}
+	return result
+}
+
+// Dropped returns the number of results removed by the retention limits
+func (r *TestRing) Dropped() uint64 {
+	if r == nil {
+		return 0
+	}
+	return r.ring.Dropped()
+}
+
+// MarshalJSON encodes the results as a list
+func (r *TestRing) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Items())
+}
+
+// size is the approximate memory used by the texts of a result
+func (t TestResult) size() int {
+	return len(t.Package) + len(t.Test) + len(t.Action) + len(t.Output)
+}
+
+func (t TestResult) stamp() time.Time {
+	return t.Time
+}
+
+// MarshalJSON adds the overflow counters of each stream
+func (b Buffer) MarshalJSON() ([]byte, error) {
+	type buffer Buffer
+	return json.Marshal(struct {
+		buffer
+		Overflow map[string]uint64 `json:"overflow"`
+	}{buffer(b), map[string]uint64{
+		"stdOut":  b.StdOut.Dropped(),
+		"stdLog":  b.StdLog.Dropped(),
+		"stdErr":  b.StdErr.Dropped(),
+		"stdTest": b.StdTest.Dropped(),
+	}})
+}
+
+// UnmarshalJSON ignores the buffer sent back by the web panel, it's owned by realize
+func (b *Buffer) UnmarshalJSON(data []byte) error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package realize
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRing_Entries(t *testing.T) {
+	r := NewRing(Retention{Entries: 3})
+	for _, text := range []string{"a", "b", "c", "d", "e"} {
+		r.Push(BufferOut{Text: text})
+	}
+	items := r.Items()
+	if len(items) != 3 || items[0].Text != "c" || items[2].Text != "e" {
+		t.Fatal("Unexpected items", items)
+	}
+	if r.Dropped() != 2 {
+		t.Error("Expected 2 dropped entries instead", r.Dropped())
+	}
+	if NewRing(Retention{}).limit.Entries != BufferEntries {
+		t.Error("Expected the default entries limit")
+	}
+	var nilRing *Ring
+	nilRing.Push(BufferOut{})
+	if nilRing.Items() != nil || nilRing.Dropped() != 0 {
+		t.Error("Unexpected nil ring")
+	}
+}
+
+func TestRing_Bytes(t *testing.T) {
+	r := NewRing(Retention{Bytes: 10})
+	for i := 0; i < 40; i++ {
+		r.Push(BufferOut{Text: "1234"})
+	}
+	if items := r.Items(); len(items) != 2 || r.Dropped() != 38 {
+		t.Error("Unexpected items", len(items), r.Dropped())
+	}
+	r.Push(BufferOut{Text: strings.Repeat("x", 20)})
+	if items := r.Items(); len(items) != 1 || len(items[0].Text) != 20 {
+		t.Error("Expected the newest entry only", items)
+	}
+}
+
+func TestRing_Age(t *testing.T) {
+	r := NewRing(Retention{Age: time.Minute})
+	r.Push(BufferOut{Time: time.Now().Add(-time.Hour), Text: "old"})
+	r.Push(BufferOut{Text: "new"})
+	if items := r.Items(); len(items) != 1 || items[0].Text != "new" || items[0].Time.IsZero() {
+		t.Error("Unexpected items", items)
+	}
+}
+
+func TestBuffer_JSON(t *testing.T) {
+	b := newBuffer(BufferSettings{Log: Retention{Entries: 1}, Test: Retention{Entries: 1}})
+	b.StdLog.Push(BufferOut{Text: "a"})
+	b.StdLog.Push(BufferOut{Text: "b"})
+	b.StdTest.Push(TestResult{Test: "TestA"}, TestResult{Test: "TestB"})
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		StdLog   []BufferOut       `json:"stdLog"`
+		StdTest  []TestResult      `json:"stdTest"`
+		Overflow map[string]uint64 `json:"overflow"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.StdLog) != 1 || result.StdLog[0].Text != "b" || result.Overflow["stdLog"] != 1 {
+		t.Error("Unexpected json", string(data))
+	}
+	if len(result.StdTest) != 1 || result.StdTest[0].Test != "TestB" || result.Overflow["stdTest"] != 1 {
+		t.Error("Unexpected json", string(data))
+	}
+	if err := json.Unmarshal(data, &b); err != nil || len(b.StdLog.Items()) != 1 {
+		t.Error("Expected the buffer untouched", err)
+	}
+}
+
+func TestTestRing(t *testing.T) {
+	r := NewTestRing(Retention{Entries: 2})
+	r.Push(TestResult{Test: "TestA"}, TestResult{Test: "TestB"})
+	r.Push(TestResult{Test: "TestC"})
+	items := r.Items()
+	if len(items) != 2 || items[0].Test != "TestB" || items[1].Test != "TestC" || items[0].Time.IsZero() {
+		t.Error("Unexpected results", items)
+	}
+	if r.Dropped() != 1 {
+		t.Error("Expected 1 dropped result instead", r.Dropped())
+	}
+	var nilRing *TestRing
+	nilRing.Push(TestResult{})
+	if nilRing.Items() != nil || nilRing.Dropped() != 0 {
+		t.Error("Unexpected nil ring")
+	}
+}
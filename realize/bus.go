@@ -0,0 +1,151 @@
+package realize
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// drop policies of a full subscription
+const (
+	DropOldest = "oldest"
+	DropNewest = "newest"
+	// Block waits a free slot of the subscription, for the subscribers that can't lose any event
+	Block = "block"
+)
+
+// SubscriberBuffer is the default number of events queued for a subscriber
+const SubscriberBuffer = 256
+
+// Bus delivers every published event to all its subscribers, a slow subscriber never blocks the publishers unless it subscribed with Block
+type Bus struct {
+	mu      sync.Mutex
+	next    uint64
+	history []Event
+	head    int
+	count   int
+	subs    map[*Subscription]bool
+}
+
+// Subscription is a bounded queue of events of a bus
+type Subscription struct {
+	bus     *Bus
+	policy  string
+	filter  func(Event) bool
+	events  chan Event
+	dropped uint64
+}
+
+// NewBus returns an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]bool)}
+}
+
+// Publish an event to all the subscribers
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.next++
+	e.ID = b.next
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	// the history is a fixed ring, the newest event replaces the oldest one
+	if b.history == nil {
+		b.history = make([]Event, EventHistory)
+	}
+	if b.count < len(b.history) {
+		b.history[(b.head+b.count)%len(b.history)] = e
+		b.count++
+	} else {
+		b.history[b.head] = e
+		b.head = (b.head + 1) % len(b.history)
+	}
+	for s := range b.subs {
+		s.send(e)
+	}
+}
+
+// Subscribe returns a subscription of the events accepted by filter, all the events if filter is nil
+func (b *Bus) Subscribe(size int, policy string, filter func(Event) bool) *Subscription {
+	if size < 1 {
+		size = SubscriberBuffer
+	}
+	s := &Subscription{bus: b, policy: policy, filter: filter, events: make(chan Event, size)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[s] = true
+	return s
+}
+
+// Since returns the events kept in the history after an id
+func (b *Bus) Since(id uint64) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var result []Event
+	for i := 0; i < b.count; i++ {
+		if e := b.history[(b.head+i)%len(b.history)]; e.ID > id {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Last returns the id of the last published event
+func (b *Bus) Last() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.next
+}
+
+// Events returns the channel of the subscription, it's closed by Close
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events lost because the subscription was full
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close removes the subscription from its bus
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if s.bus.subs[s] {
+		delete(s.bus.subs, s)
+		close(s.events)
+	}
+}
+
+// send queues an event, called with the bus lock held
+func (s *Subscription) send(e Event) {
+	if s.filter != nil && !s.filter(e) {
+		return
+	}
+	if s.policy == Block {
+		s.events <- e
+		return
+	}
+	select {
+	case s.events <- e:
+		return
+	default:
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	if s.policy != DropOldest {
+		return
+	}
+	// make room discarding the oldest queued event
+	select {
+	case <-s.events:
+	default:
+	}
+	select {
+	case s.events <- e:
+	default:
+	}
+}
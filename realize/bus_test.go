@@ -0,0 +1,82 @@
+package realize
+
+import (
+	"testing"
+)
+
+func TestBus_Publish(t *testing.T) {
+	b := NewBus()
+	first := b.Subscribe(10, DropNewest, nil)
+	second := b.Subscribe(10, DropNewest, func(e Event) bool {
+		return e.Type == EventBuildFailed
+	})
+	b.Publish(Event{Type: EventBuildStarted})
+	b.Publish(Event{Type: EventBuildFailed})
+	if len(first.Events()) != 2 || len(second.Events()) != 1 {
+		t.Fatal("Unexpected queued events", len(first.Events()), len(second.Events()))
+	}
+	if e := <-second.Events(); e.ID != 2 || e.Time.IsZero() {
+		t.Error("Unexpected event", e)
+	}
+	second.Close()
+	second.Close()
+	b.Publish(Event{Type: EventBuildFailed})
+	if _, ok := <-second.Events(); ok {
+		t.Error("Expected a closed subscription")
+	}
+	if len(first.Events()) != 3 {
+		t.Error("Expected 3 events instead", len(first.Events()))
+	}
+	var nilBus *Bus
+	nilBus.Publish(Event{})
+}
+
+func TestBus_Drop(t *testing.T) {
+	b := NewBus()
+	newest := b.Subscribe(2, DropNewest, nil)
+	oldest := b.Subscribe(2, DropOldest, nil)
+	for i := 0; i < 5; i++ {
+		b.Publish(Event{Type: EventRunOutput})
+	}
+	if newest.Dropped() != 3 || oldest.Dropped() != 3 {
+		t.Fatal("Unexpected dropped events", newest.Dropped(), oldest.Dropped())
+	}
+	if a, b := <-newest.Events(), <-newest.Events(); a.ID != 1 || b.ID != 2 {
+		t.Error("Expected the first events", a.ID, b.ID)
+	}
+	if a, b := <-oldest.Events(), <-oldest.Events(); a.ID != 4 || b.ID != 5 {
+		t.Error("Expected the last events", a.ID, b.ID)
+	}
+}
+
+func TestBus_Since(t *testing.T) {
+	b := NewBus()
+	for i := 0; i < EventHistory+10; i++ {
+		b.Publish(Event{Type: EventRunOutput})
+	}
+	list := b.Since(0)
+	if len(list) != EventHistory || list[0].ID != 11 {
+		t.Fatal("Unexpected history", len(list), list[0].ID)
+	}
+	if list := b.Since(EventHistory + 5); len(list) != 5 {
+		t.Error("Expected 5 events instead", len(list))
+	}
+}
+
+func TestBus_Block(t *testing.T) {
+	b := NewBus()
+	all := b.Subscribe(2, Block, nil)
+	go func() {
+		for i := 0; i < 100; i++ {
+			b.Publish(Event{Type: EventOutput})
+		}
+		all.Close()
+	}()
+	var ids []uint64
+	for e := range all.Events() {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 100 || ids[0] != 1 || ids[99] != 100 || all.Dropped() != 0 {
+		t.Error("Expected every event in order", len(ids), all.Dropped())
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"go/build"
 	"log"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -34,24 +33,32 @@ type (
 
 	// Realize main struct
 	Realize struct {
-		Settings Settings `yaml:"settings" json:"settings"`
-		Server   Server   `yaml:"server,omitempty" json:"server,omitempty"`
-		Schema   `yaml:",inline" json:",inline"`
-		Sync     chan string `yaml:"-" json:"-"`
-		Err      Func        `yaml:"-" json:"-"`
-		After    Func        `yaml:"-"  json:"-"`
-		Before   Func        `yaml:"-"  json:"-"`
-		Change   Func        `yaml:"-"  json:"-"`
-		Reload   Func        `yaml:"-"  json:"-"`
+		Settings    Settings `yaml:"settings" json:"settings"`
+		Server      Server   `yaml:"server,omitempty" json:"server,omitempty"`
+		Schema      `yaml:",inline" json:",inline"`
+		Bus         *Bus `yaml:"-" json:"-"`
+		WatchConfig bool `yaml:"-" json:"-"`
+		wg          *sync.WaitGroup
+		snapshots   map[string]string
+		sections    string
+		mu          sync.RWMutex
+		running     map[string]*Project
+		Err         Func `yaml:"-" json:"-"`
+		After       Func `yaml:"-"  json:"-"`
+		Before      Func `yaml:"-"  json:"-"`
+		Change      Func `yaml:"-"  json:"-"`
+		Reload      Func `yaml:"-"  json:"-"`
 	}
 
 	// Context is used as argument for func
 	Context struct {
 		Path    string
+		Paths   []string
 		Project *Project
 		Stop    <-chan bool
 		Watcher FileWatcher
 		Event   fsnotify.Event
+		Events  []fsnotify.Event
 	}
 
 	// Func is used instead realize func
@@ -74,9 +81,9 @@ func init() {
 
 // Stop realize workflow
 func (r *Realize) Stop() error {
-	for k := range r.Schema.Projects {
-		if r.Schema.Projects[k].exit != nil {
-			close(r.Schema.Projects[k].exit)
+	for _, p := range r.projects() {
+		if p.exit != nil {
+			close(p.exit)
 		}
 	}
 	return nil
@@ -85,15 +92,44 @@ func (r *Realize) Stop() error {
 // Start realize workflow
 func (r *Realize) Start() error {
 	if len(r.Schema.Projects) > 0 {
-		var wg sync.WaitGroup
-		wg.Add(len(r.Schema.Projects))
+		if r.Bus == nil {
+			r.Bus = NewBus()
+		}
+		// the log files keep every line
+		files := r.Bus.Subscribe(SubscriberBuffer, Block, func(e Event) bool {
+			return e.Type == EventOutput
+		})
+		recorded := make(chan struct{})
+		go func() {
+			r.Settings.record(files.Events())
+			close(recorded)
+		}()
+		// the queued lines are written and the files closed before returning
+		defer func() {
+			files.Close()
+			<-recorded
+		}()
+		order, err := r.Schema.order(false)
+		if err != nil {
+			return err
+		}
+		r.wg = &sync.WaitGroup{}
+		r.snapshots = make(map[string]string)
 		for k := range r.Schema.Projects {
-			r.Schema.Projects[k].exit = make(chan os.Signal, 1)
-			signal.Notify(r.Schema.Projects[k].exit, os.Interrupt)
-			r.Schema.Projects[k].parent = r
-			go r.Schema.Projects[k].Watch(&wg)
+			r.Schema.Projects[k].up = newLatch()
+			r.Schema.Projects[k].cascade = make(chan struct{}, 1)
+			r.snapshots[r.Schema.Projects[k].Name] = snapshot(&r.Schema.Projects[k])
+		}
+		// the projects are started after their dependencies
+		for _, k := range order {
+			r.run(&r.Schema.Projects[k])
+		}
+		if r.WatchConfig {
+			stop := make(chan struct{})
+			defer close(stop)
+			go r.config(stop)
 		}
-		wg.Wait()
+		r.wg.Wait()
 	} else {
 		return errors.New("there are no projects")
 	}
@@ -0,0 +1,195 @@
+package realize
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// run inits a project and starts its watcher
+func (r *Realize) run(p *Project) {
+	p.exit = make(chan os.Signal, 1)
+	signal.Notify(p.exit, os.Interrupt)
+	p.parent = r
+	p.state = &state{}
+	p.writes = &writes{}
+	p.Buffer = newBuffer(r.Settings.Buffer)
+	p.control = make(chan string)
+	p.done = make(chan struct{})
+	r.mu.Lock()
+	if r.running == nil {
+		r.running = make(map[string]*Project)
+	}
+	r.running[p.Name] = p
+	r.mu.Unlock()
+	r.wg.Add(1)
+	go func() {
+		defer close(p.done)
+		p.Watch(r.wg)
+	}()
+}
+
+// projects returns the running projects in the schema order
+func (r *Realize) projects() []*Project {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.live()
+}
+
+// live returns the running projects in the schema order, the configured ones if not running, called with the lock held
+func (r *Realize) live() []*Project {
+	result := make([]*Project, 0, len(r.Schema.Projects))
+	for k := range r.Schema.Projects {
+		if p, ok := r.running[r.Schema.Projects[k].Name]; ok {
+			result = append(result, p)
+			continue
+		}
+		result = append(result, &r.Schema.Projects[k])
+	}
+	return result
+}
+
+// quit stops the watcher of a project and waits its end
+func (p *Project) quit() {
+	if p.exit == nil {
+		return
+	}
+	signal.Stop(p.exit)
+	select {
+	case p.exit <- os.Interrupt:
+	default:
+	}
+	<-p.done
+}
+
+// snapshot returns the definition of a project, used to find the changed ones
+func snapshot(p *Project) string {
+	out, _ := yaml.Marshal(p)
+	return string(out)
+}
+
+// sections returns the definition of the settings and the server, they're applied only on start
+func sections(r *Realize) string {
+	out, _ := yaml.Marshal(struct {
+		Settings *Settings `yaml:"settings"`
+		Server   *Server   `yaml:"server"`
+	}{&r.Settings, &r.Server})
+	return string(out)
+}
+
+// config watches the config file until stop is closed
+func (r *Realize) config(stop <-chan struct{}) {
+	watcher, err := NewFileWatcher(r.Settings.Legacy)
+	if err != nil {
+		log.Println(r.Prefix(Red.Regular(err.Error())))
+		return
+	}
+	defer watcher.Close()
+	file, _ := filepath.Abs(RFile)
+	// the settings and the server of the file, their changes aren't applied
+	if content, err := ioutil.ReadFile(RFile); err == nil {
+		var current Realize
+		if yaml.Unmarshal(content, &current) == nil {
+			r.sections = sections(&current)
+		}
+	}
+	// the directory catches the editors replacing the file
+	watcher.Add(filepath.Dir(file))
+	watcher.Add(file)
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-watcher.Events():
+			if filepath.Base(event.Name) != RFile {
+				continue
+			}
+			watcher.Add(file)
+			reload = time.After(Debounce)
+		case <-watcher.Errors():
+		case <-reload:
+			reload = nil
+			if err := r.reconfigure(); err != nil {
+				log.Println(r.Prefix(Red.Regular("Invalid config, the current one is kept: ", err.Error())))
+			}
+		}
+	}
+}
+
+// reconfigure reads the config file and restarts only the changed projects
+func (r *Realize) reconfigure() error {
+	content, err := ioutil.ReadFile(RFile)
+	if err != nil {
+		return err
+	}
+	if problems := Validate(content); len(problems) > 0 {
+		return problems
+	}
+	var next Realize
+	if err := yaml.Unmarshal(content, &next); err != nil {
+		return err
+	}
+	if s := sections(&next); s != r.sections {
+		r.sections = s
+		log.Println(r.Prefix(Yellow.Regular("The changes of settings and server need a restart")))
+	}
+	running := make(map[string]*Project)
+	for _, p := range r.projects() {
+		if p.done != nil {
+			running[p.Name] = p
+		}
+	}
+	kept := make(map[string]bool)
+	var started, stopped []string
+	for k := range next.Schema.Projects {
+		p := &next.Schema.Projects[k]
+		// the unchanged projects keep running
+		if _, ok := running[p.Name]; ok && r.snapshots[p.Name] == snapshot(p) {
+			kept[p.Name] = true
+			delete(running, p.Name)
+			continue
+		}
+		started = append(started, p.Name)
+	}
+	if len(started) == 0 && len(running) == 0 {
+		return nil
+	}
+	if len(next.Schema.Projects) == 0 {
+		return errors.New("there are no projects")
+	}
+	// keep realize running while the changed projects restart
+	r.wg.Add(1)
+	defer r.wg.Done()
+	for name, p := range running {
+		p.quit()
+		delete(r.snapshots, name)
+		stopped = append(stopped, name)
+	}
+	r.mu.Lock()
+	r.Schema.Projects = next.Schema.Projects
+	for _, name := range stopped {
+		delete(r.running, name)
+	}
+	r.mu.Unlock()
+	order, _ := r.Schema.order(false)
+	for _, k := range order {
+		p := &r.Schema.Projects[k]
+		if kept[p.Name] {
+			continue
+		}
+		p.up = newLatch()
+		p.cascade = make(chan struct{}, 1)
+		r.snapshots[p.Name] = snapshot(p)
+		r.run(p)
+	}
+	log.Println(r.Prefix("Config reloaded, started [" + strings.Join(started, " ") + "] stopped [" + strings.Join(stopped, " ") + "]"))
+	return nil
+}
@@ -0,0 +1,81 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestRealize_Reconfigure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	write := func(content string) {
+		if err := ioutil.WriteFile(RFile, []byte(content), Permission); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := Realize{wg: &sync.WaitGroup{}, snapshots: make(map[string]string)}
+	r.Projects = []Project{{Name: "a", Path: dir}, {Name: "b", Path: dir}}
+	for k := range r.Projects {
+		r.Projects[k].up = newLatch()
+		r.Projects[k].cascade = make(chan struct{}, 1)
+		r.snapshots[r.Projects[k].Name] = snapshot(&r.Projects[k])
+		r.run(&r.Projects[k])
+	}
+	first := &r.Projects[0]
+	a, b := r.Projects[0].done, r.Projects[1].done
+
+	write("schema:\n- name: a\n  path: " + dir + "\n- name: a\n  path: " + dir + "\n")
+	if err := r.reconfigure(); err == nil {
+		t.Error("Expected a duplicated project error")
+	}
+	write("schema:\n- name: a\n  path: " + dir + "\n  depends_on: [a]\n")
+	if err := r.reconfigure(); err == nil {
+		t.Error("Expected a dependency cycle error")
+	}
+	if projects := r.projects(); len(projects) != 2 || projects[1].done != b {
+		t.Fatal("Expected the current config kept")
+	}
+
+	write("schema:\n- name: a\n  path: " + dir + "\n- name: b\n  path: " + dir + "\n  args: [-v]\n- name: c\n  path: " + dir + "\n")
+	if err := r.reconfigure(); err != nil {
+		t.Fatal(err)
+	}
+	projects := r.projects()
+	if len(projects) != 3 || projects[0] != first || projects[0].done != a {
+		t.Fatal("Expected the unchanged project still running", len(projects))
+	}
+	select {
+	case <-b:
+	default:
+		t.Error("Expected the changed project stopped")
+	}
+	if projects[1].done == b || projects[1].Args[0] != "-v" || projects[2].done == nil {
+		t.Error("Expected the changed and the new project started")
+	}
+
+	write("settings:\n  legacy:\n    force: true\nschema:\n- name: c\n  path: " + dir + "\n")
+	if err := r.reconfigure(); err != nil {
+		t.Fatal(err)
+	}
+	if projects := r.projects(); len(projects) != 1 || projects[0].Name != "c" {
+		t.Fatal("Unexpected projects", len(projects))
+	}
+	if r.Settings.Legacy.Force || r.sections == "" {
+		t.Error("Expected the settings changes kept for a restart")
+	}
+	r.Stop()
+	r.wg.Wait()
+}
@@ -0,0 +1,142 @@
+package realize
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// latch is opened once, when a project is ready for the first time
+type latch struct {
+	once sync.Once
+	done chan struct{}
+}
+
+func newLatch() *latch {
+	return &latch{done: make(chan struct{})}
+}
+
+// open the latch, returns false if it was already open
+func (l *latch) open() (first bool) {
+	l.once.Do(func() {
+		close(l.done)
+		first = true
+	})
+	return
+}
+
+// Order returns the indexes of the projects, each one after its dependencies
+func (s *Schema) Order() ([]int, error) {
+	return s.order(true)
+}
+
+// order sorts the projects by their dependencies, the unknown ones are an error only if strict
+func (s *Schema) order(strict bool) ([]int, error) {
+	index := make(map[string]int, len(s.Projects))
+	for i, p := range s.Projects {
+		index[p.Name] = i
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make([]int, len(s.Projects))
+	result := make([]int, 0, len(s.Projects))
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		path = append(path, s.Projects[i].Name)
+		switch marks[i] {
+		case visiting:
+			return fmt.Errorf("dependency cycle %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+		marks[i] = visiting
+		for _, name := range s.Projects[i].DependsOn {
+			dep, ok := index[name]
+			if !ok {
+				if strict {
+					return fmt.Errorf("project %s depends on the unknown project %s", s.Projects[i].Name, name)
+				}
+				continue
+			}
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		marks[i] = visited
+		result = append(result, i)
+		return nil
+	}
+	for i := range s.Projects {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// upstream returns the running projects the project depends on
+func (p *Project) upstream() (result []*Project) {
+	projects := p.parent.projects()
+	for _, name := range p.DependsOn {
+		for _, up := range projects {
+			if up.Name == name {
+				result = append(result, up)
+			}
+		}
+	}
+	return
+}
+
+// downstream returns the running projects depending on the project
+func (p *Project) downstream() (result []*Project) {
+	for _, down := range p.parent.projects() {
+		for _, name := range down.DependsOn {
+			if name == p.Name {
+				result = append(result, down)
+			}
+		}
+	}
+	return
+}
+
+// depends waits the first readiness of the dependencies, returns false if realize exits before
+func (p *Project) depends() bool {
+	for _, dep := range p.upstream() {
+		if dep.up == nil {
+			continue
+		}
+		select {
+		case <-dep.up.done:
+			continue
+		default:
+		}
+		p.state.set(StateWaiting)
+		log.Println(p.pname(p.Name, 1), ":", "Waiting for", dep.Name)
+		select {
+		case <-dep.up.done:
+		case <-p.exit:
+			return false
+		}
+	}
+	return true
+}
+
+// started marks the project as ready, the next times it rebuilds the projects depending on it
+func (p *Project) started() {
+	if p.up == nil || p.up.open() {
+		return
+	}
+	for _, down := range p.downstream() {
+		if down.cascade == nil {
+			continue
+		}
+		// pending rebuilds are coalesced
+		select {
+		case down.cascade <- struct{}{}:
+		default:
+		}
+	}
+}
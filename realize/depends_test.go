@@ -0,0 +1,84 @@
+package realize
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchema_Order(t *testing.T) {
+	s := Schema{Projects: []Project{
+		{Name: "gateway", DependsOn: []string{"auth", "shared"}},
+		{Name: "auth", DependsOn: []string{"shared"}},
+		{Name: "shared"},
+	}}
+	order, err := s.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, i := range order {
+		names = append(names, s.Projects[i].Name)
+	}
+	if strings.Join(names, ",") != "shared,auth,gateway" {
+		t.Error("Unexpected order", names)
+	}
+	s.Projects[2].DependsOn = []string{"gateway"}
+	if _, err := s.Order(); err == nil || !strings.Contains(err.Error(), "gateway -> auth -> shared -> gateway") {
+		t.Error("Expected a cycle error", err)
+	}
+	s.Projects[2].DependsOn = []string{"missing"}
+	if _, err := s.Order(); err == nil {
+		t.Error("Expected an unknown project error")
+	}
+	if order, err := s.order(false); err != nil || len(order) != 3 {
+		t.Error("Expected the unknown projects ignored", order, err)
+	}
+}
+
+func TestProject_Depends(t *testing.T) {
+	r := Realize{}
+	r.Projects = []Project{
+		{Name: "auth", up: newLatch(), cascade: make(chan struct{}, 1)},
+		{Name: "gateway", DependsOn: []string{"auth"}, up: newLatch(), cascade: make(chan struct{}, 1), state: &state{}, exit: make(chan os.Signal, 1)},
+	}
+	for k := range r.Projects {
+		r.Projects[k].parent = &r
+	}
+	auth, gateway := &r.Projects[0], &r.Projects[1]
+	result := make(chan bool)
+	go func() {
+		result <- gateway.depends()
+	}()
+	select {
+	case <-result:
+		t.Fatal("Expected to wait the dependency")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if gateway.Status().State != StateWaiting {
+		t.Error("Expected waiting state", gateway.Status().State)
+	}
+	// the first readiness doesn't cascade
+	auth.started()
+	if !<-result {
+		t.Error("Expected the dependency ready")
+	}
+	if len(gateway.cascade) != 0 {
+		t.Fatal("Unexpected cascade")
+	}
+	auth.started()
+	auth.started()
+	if len(gateway.cascade) != 1 {
+		t.Error("Expected a coalesced cascade")
+	}
+	gateway.up = newLatch()
+	r.Projects[0].up = newLatch()
+	go func() {
+		result <- gateway.depends()
+	}()
+	gateway.exit <- os.Interrupt
+	if <-result {
+		t.Error("Expected an exit while waiting")
+	}
+}
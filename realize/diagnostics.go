@@ -0,0 +1,97 @@
+package realize
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diagnostics severity
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a file:line:column message reported by a go tool
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Tool     string `json:"tool"`
+	Message  string `json:"message"`
+}
+
+var diagnosticLine = regexp.MustCompile(`^(?:vet: )?(\S+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// String returns the diagnostic in the go toolchain format
+func (d Diagnostic) String() string {
+	if d.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+// diagnostics parses the output of a go tool, relative files are resolved from dir
+func diagnostics(tool string, dir string, output string) (result []Diagnostic) {
+	severity := SeverityError
+	if tool == "Vet" {
+		severity = SeverityWarning
+	}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		text := scanner.Text()
+		// indented lines continue the previous message
+		if strings.HasPrefix(text, "\t") && len(result) > 0 {
+			result[len(result)-1].Message += "\n" + strings.TrimSpace(text)
+			continue
+		}
+		match := diagnosticLine.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		d := Diagnostic{File: match[1], Severity: severity, Tool: tool, Message: match[4]}
+		d.Line, _ = strconv.Atoi(match[2])
+		d.Column, _ = strconv.Atoi(match[3])
+		if !filepath.IsAbs(d.File) && dir != "" {
+			d.File = filepath.Join(dir, d.File)
+		}
+		result = append(result, d)
+	}
+	return
+}
+
+// groupDiagnostics formats a list of diagnostics grouped by file, in order of appearance
+func groupDiagnostics(list []Diagnostic) string {
+	var files []string
+	groups := make(map[string][]Diagnostic)
+	for _, d := range list {
+		if _, ok := groups[d.File]; !ok {
+			files = append(files, d.File)
+		}
+		groups[d.File] = append(groups[d.File], d)
+	}
+	var b strings.Builder
+	for _, file := range files {
+		b.WriteString(Magenta.Bold(file) + "\n")
+		for _, d := range groups[file] {
+			position := strconv.Itoa(d.Line)
+			if d.Column > 0 {
+				position += ":" + strconv.Itoa(d.Column)
+			}
+			b.WriteString("  " + position + " " + d.Message + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// diagnosticErrors returns the diagnostics as plain strings
+func diagnosticErrors(list []Diagnostic) (errors []string) {
+	for _, d := range list {
+		errors = append(errors, d.String())
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package realize
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	output := strings.Join([]string{
+		"# example.com/m",
+		"./main.go:12:3: undefined: x",
+		"./main.go:14:2: cannot use y (type int) as type string",
+		"\thave (int)",
+		"vet: util/a.go:3:9: unreachable code",
+		"/abs/b.go:7: missing return",
+		"exit status 2",
+	}, "\n")
+	dir := filepath.FromSlash("/project")
+	result := diagnostics("Build", dir, output)
+	if len(result) != 4 {
+		t.Fatal("Expected 4 diagnostics instead", len(result), result)
+	}
+	first := result[0]
+	if first.File != filepath.Join(dir, "main.go") || first.Line != 12 || first.Column != 3 || first.Message != "undefined: x" {
+		t.Error("Unexpected diagnostic", first)
+	}
+	if first.Severity != SeverityError || first.Tool != "Build" {
+		t.Error("Unexpected severity or tool", first)
+	}
+	if !strings.HasSuffix(result[1].Message, "\nhave (int)") {
+		t.Error("Expected continuation line", result[1].Message)
+	}
+	if result[2].File != filepath.Join(dir, "util", "a.go") {
+		t.Error("Unexpected file", result[2].File)
+	}
+	if result[3].Column != 0 || result[3].String() != "/abs/b.go:7: missing return" {
+		t.Error("Unexpected diagnostic", result[3])
+	}
+	if vet := diagnostics("Vet", dir, "main.go:1:1: x"); len(vet) != 1 || vet[0].Severity != SeverityWarning {
+		t.Error("Expected vet warning", vet)
+	}
+	grouped := groupDiagnostics(result)
+	if strings.Count(grouped, "main.go") != 1 || !strings.Contains(grouped, "12:3 undefined: x") {
+		t.Error("Unexpected grouping", grouped)
+	}
+}
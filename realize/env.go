@@ -0,0 +1,178 @@
+package realize
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ${VAR} and ${VAR:-default} references
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expansion is the config of a project with the environment references expanded,
+// it's replaced as a whole when an env file changes and the config fields keep the raw values
+type expansion struct {
+	path   string
+	args   []string
+	tools  map[*Tool][]string
+	env    map[string]string
+	lookup func(string) (string, bool)
+}
+
+// command expands a script, the scripts can change after the expansion so they're expanded when they run
+func (x *expansion) command(cmd string) string {
+	if x.lookup == nil {
+		return cmd
+	}
+	return expand(cmd, x.lookup)
+}
+
+// expand replaces the environment references, the default is used if a variable is unset or empty
+func expand(s string, lookup func(string) (string, bool)) string {
+	return envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		match := envRef.FindStringSubmatch(ref)
+		if v, ok := lookup(match[1]); ok && (v != "" || match[2] == "") {
+			return v
+		}
+		return match[3]
+	})
+}
+
+// parseEnv reads a dotenv file: KEY=value lines, # comments, an optional export and quoted values
+func parseEnv(content string) (map[string]string, error) {
+	result := make(map[string]string)
+	for n, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, errors.New("line " + strconv.Itoa(n+1) + ": expected KEY=value")
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, errors.New("line " + strconv.Itoa(n+1) + ": " + err.Error())
+			}
+			value = unquoted
+		case len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if c := strings.Index(value, " #"); c >= 0 {
+				value = strings.TrimSpace(value[:c])
+			}
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// envFiles returns the paths of the env files of the project
+func (p *Project) envFiles() (files []string) {
+	for _, name := range p.EnvFile {
+		name = expand(name, os.LookupEnv)
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(p.dir(), name)
+		}
+		files = append(files, name)
+	}
+	return
+}
+
+// dir returns the path of the project, expanded by the os environment
+func (p *Project) dir() string {
+	return expand(p.Path, os.LookupEnv)
+}
+
+// expanded returns the current expansion of the config, the raw values if the environment isn't loaded
+func (p *Project) expanded() *expansion {
+	if x, ok := p.resolved.Load().(*expansion); ok {
+		return x
+	}
+	x := &expansion{path: p.dir(), args: p.Args, tools: make(map[*Tool][]string)}
+	for _, t := range p.Tools.list() {
+		x.tools[t] = t.Args
+	}
+	return x
+}
+
+// environment loads the env files and expands the references of the project config,
+// the variables are looked up in env, then in the env files and then in the os environment
+func (p *Project) environment() error {
+	files := make(map[string]string)
+	var errs []string
+	for _, name := range p.envFiles() {
+		content, err := ioutil.ReadFile(name)
+		if err == nil {
+			var values map[string]string
+			if values, err = parseEnv(string(content)); err == nil {
+				for k, v := range values {
+					files[k] = v
+				}
+				continue
+			}
+		}
+		errs = append(errs, fmt.Sprint(name, ": ", err))
+	}
+	// env can reference the env files and the os environment
+	env := make(map[string]string)
+	for k, v := range files {
+		env[k] = v
+	}
+	for k, v := range p.Env {
+		env[k] = expand(v, func(key string) (string, bool) {
+			if v, ok := files[key]; ok {
+				return v, true
+			}
+			return os.LookupEnv(key)
+		})
+	}
+	lookup := func(key string) (string, bool) {
+		if v, ok := env[key]; ok {
+			return v, true
+		}
+		return os.LookupEnv(key)
+	}
+	x := &expansion{path: p.dir(), env: env, lookup: lookup, args: expandAll(p.Args, lookup), tools: make(map[*Tool][]string)}
+	// an expanded value can hold more arguments
+	for _, t := range p.Tools.list() {
+		x.tools[t] = split([]string{}, expandAll(t.Args, lookup))
+	}
+	// the running reloads keep their expansion
+	p.resolved.Store(x)
+	if len(errs) > 0 {
+		return errors.New("env file " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func expandAll(list []string, lookup func(string) (string, bool)) []string {
+	if list == nil {
+		return nil
+	}
+	result := make([]string, len(list))
+	for i, v := range list {
+		result[i] = expand(v, lookup)
+	}
+	return result
+}
+
+// isEnvFile checks if a path is one of the env files of the project
+func (p *Project) isEnvFile(path string) bool {
+	path, _ = filepath.Abs(path)
+	for _, name := range p.envFiles() {
+		if name, _ = filepath.Abs(name); name == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		values := map[string]string{"HOST": "localhost", "EMPTY": ""}
+		v, ok := values[key]
+		return v, ok
+	}
+	for in, out := range map[string]string{
+		"${HOST}:80":           "localhost:80",
+		"${PORT:-8080}":        "8080",
+		"${EMPTY:-default}":    "default",
+		"${EMPTY}x${MISSING}x": "xx",
+		"$HOST ${HOST:-other}": "$HOST localhost",
+		"${PORT:-}":            "",
+	} {
+		if result := expand(in, lookup); result != out {
+			t.Error("Unexpected expansion of", in, result)
+		}
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	values, err := parseEnv("# comment\n\nexport TOKEN=secret\nNAME = realize # inline\nQUOTED=\"a b\\nc\"\nSINGLE='${RAW}'\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"TOKEN": "secret", "NAME": "realize", "QUOTED": "a b\nc", "SINGLE": "${RAW}"}
+	for k, v := range expected {
+		if values[k] != v {
+			t.Error("Unexpected value of", k, values[k])
+		}
+	}
+	if _, err := parseEnv("A=1\ninvalid\n"); err == nil || !strings.HasPrefix(err.Error(), "line 2") {
+		t.Error("Expected an error at line 2", err)
+	}
+}
+
+func TestProject_Environment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("REALIZE_TEST_DIR", dir)
+	os.Setenv("REALIZE_TEST_OS", "os")
+	defer os.Unsetenv("REALIZE_TEST_DIR")
+	defer os.Unsetenv("REALIZE_TEST_OS")
+	if err := ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=3000\nREALIZE_TEST_OS=file\n"), Permission); err != nil {
+		t.Fatal(err)
+	}
+	p := Project{
+		Path:    "${REALIZE_TEST_DIR}",
+		EnvFile: []string{".env", "missing.env"},
+		Env:     map[string]string{"ADDR": "localhost:${PORT}", "PORT": "4000"},
+		Args:    []string{"--port=${PORT}", "--os=${REALIZE_TEST_OS}"},
+		Tools:   Tools{Run: Tool{Args: []string{"${ADDR:-none}"}}},
+		Watcher: Watch{Scripts: []Command{{Cmd: "echo ${REALIZE_TEST_OS}"}}},
+	}
+	if err := p.environment(); err == nil || !strings.Contains(err.Error(), "missing.env") {
+		t.Error("Expected a missing env file error", err)
+	}
+	x := p.expanded()
+	if x.path != dir || !p.isEnvFile(filepath.Join(dir, ".env")) {
+		t.Error("Unexpected path", x.path)
+	}
+	// env overrides the env files, which override the os environment
+	if x.args[0] != "--port=4000" || x.args[1] != "--os=file" {
+		t.Error("Unexpected args", x.args)
+	}
+	if x.tools[&p.Tools.Run][0] != "localhost:3000" || x.command(p.Watcher.Scripts[0].Cmd) != "echo file" {
+		t.Error("Unexpected expansion", x.tools[&p.Tools.Run])
+	}
+	envs := strings.Join(p.buildEnvs(nil), "\n")
+	if !strings.Contains(envs, "REALIZE_TEST_OS=file") || !strings.Contains(envs, "ADDR=localhost:3000") || !strings.Contains(envs, "PATH=") {
+		t.Error("Unexpected envs", envs)
+	}
+	// a changed env file replaces the expansion, the previous one is unchanged
+	ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=5000\n"), Permission)
+	p.environment()
+	if next := p.expanded(); next.tools[&p.Tools.Run][0] != "localhost:5000" || next.args[1] != "--os=os" {
+		t.Error("Unexpected expansion after a change", next.tools[&p.Tools.Run], next.args)
+	}
+	if x.args[0] != "--port=4000" {
+		t.Error("Expected the previous expansion unchanged", x.args)
+	}
+	// the config keeps the references, as written in the config file
+	if p.Path != "${REALIZE_TEST_DIR}" || p.Args[0] != "--port=${PORT}" || p.Tools.Run.Args[0] != "${ADDR:-none}" ||
+		p.Watcher.Scripts[0].Cmd != "echo ${REALIZE_TEST_OS}" || p.Env["ADDR"] != "localhost:${PORT}" {
+		t.Error("Expected the config unchanged", p.Path, p.Args, p.Tools.Run.Args, p.Watcher.Scripts[0].Cmd, p.Env)
+	}
+}
+
+func TestProject_BuildEnvsOverride(t *testing.T) {
+	os.Setenv("REALIZE_TEST_LEVEL", "os")
+	defer os.Unsetenv("REALIZE_TEST_LEVEL")
+	p := Project{Env: map[string]string{"REALIZE_TEST_LEVEL": "project", "CGO_ENABLED": "0"}}
+	// the last value of a variable is the one used by a subprocess
+	value := func(envs []string, key string) (v string) {
+		for _, e := range envs {
+			if strings.HasPrefix(e, key+"=") {
+				v = strings.TrimPrefix(e, key+"=")
+			}
+		}
+		return
+	}
+	envs := p.buildEnvs(nil)
+	if value(envs, "REALIZE_TEST_LEVEL") != "project" || value(envs, "CGO_ENABLED") != "0" {
+		t.Error("Unexpected envs", envs)
+	}
+	envs = p.buildEnvs(map[string]string{"REALIZE_TEST_LEVEL": "tool", "GOFLAGS": "-tags=cgo${CGO_ENABLED}"})
+	if value(envs, "REALIZE_TEST_LEVEL") != "tool" || value(envs, "GOFLAGS") != "-tags=cgo0" || value(envs, "CGO_ENABLED") != "0" {
+		t.Error("Unexpected envs with overrides", envs)
+	}
+}
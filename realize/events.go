@@ -0,0 +1,121 @@
+package realize
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// event types
+const (
+	EventFileChanged   = "file_changed"
+	EventBuildStarted  = "build_started"
+	EventBuildFailed   = "build_failed"
+	EventTestResult    = "test_result"
+	EventRunOutput     = "run_output"
+	EventProcessExited = "process_exited"
+	EventOutput        = "output"
+)
+
+// EventHistory is the number of events kept to resume a stream
+const EventHistory = 1000
+
+// heartbeat interval of the events stream
+const eventHeartbeat = 15 * time.Second
+
+// Event is a typed notification about a project
+type Event struct {
+	ID      uint64      `json:"id"`
+	Type    string      `json:"type"`
+	Project string      `json:"project"`
+	Time    time.Time   `json:"time"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Line is the data of an output event, a line printed by a project on one of its streams
+type Line struct {
+	Stream string    `json:"stream"`
+	Path   string    `json:"path"`
+	Text   string    `json:"text"`
+	Out    BufferOut `json:"out"`
+}
+
+// publish an event of the project
+func (p *Project) publish(kind string, data interface{}) {
+	if p.parent == nil {
+		return
+	}
+	p.parent.Bus.Publish(Event{Type: kind, Project: p.Name, Data: data})
+}
+
+// Events streams the realize events as server sent events
+func (s *Server) events(c echo.Context) error {
+	bus := s.Parent.Bus
+	if bus == nil {
+		return c.JSON(http.StatusServiceUnavailable, apiError{"events aren't available"})
+	}
+	flusher, ok := c.Response().Writer.(http.Flusher)
+	if !ok {
+		return c.JSON(http.StatusInternalServerError, apiError{"streaming unsupported"})
+	}
+	filter := func(param string) map[string]bool {
+		values := make(map[string]bool)
+		for _, v := range strings.Split(c.QueryParam(param), ",") {
+			if v != "" {
+				values[v] = true
+			}
+		}
+		return values
+	}
+	projects, types := filter("project"), filter("type")
+	last := c.Request().Header.Get("Last-Event-ID")
+	if last == "" {
+		last = c.QueryParam("last_event_id")
+	}
+	// a new client gets only the next events, the history is replayed on resume
+	id := bus.Last()
+	if last != "" {
+		id, _ = strconv.ParseUint(last, 10, 64)
+	}
+	rs := c.Response()
+	rs.Header().Set(echo.HeaderContentType, "text/event-stream")
+	rs.Header().Set("Cache-Control", "no-cache")
+	rs.Header().Set("Connection", "keep-alive")
+	rs.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	// the subscription only wakes up the stream, the events are read from the history
+	notify := bus.Subscribe(1, DropNewest, nil)
+	defer notify.Close()
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		for _, e := range bus.Since(id) {
+			id = e.ID
+			if len(projects) > 0 && !projects[e.Project] || len(types) > 0 && !types[e.Type] {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(rs, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return nil
+			}
+		}
+		flusher.Flush()
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(rs, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case <-notify.Events():
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package realize
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func TestServer_Events(t *testing.T) {
+	r := Realize{Bus: NewBus()}
+	r.Projects = []Project{{Name: "a", parent: &r}, {Name: "b", parent: &r}}
+	r.Projects[0].publish(EventFileChanged, nil)
+	r.Projects[1].publish(EventFileChanged, nil)
+	r.Projects[0].publish(EventBuildStarted, nil)
+	r.Projects[0].publish(EventBuildFailed, nil)
+	s := Server{Parent: &r}
+	e := echo.New()
+	e.GET("/events", s.events)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events?project=a", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Error("Unexpected content type", resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+	var ids []string
+	for len(ids) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "id: ") {
+			ids = append(ids, strings.TrimSpace(line[4:]))
+		}
+		if len(ids) == 2 && strings.HasPrefix(line, "data: ") {
+			// events published after the connection are streamed as well
+			r.Projects[1].publish(EventRunOutput, nil)
+			r.Projects[0].publish(EventProcessExited, nil)
+		}
+	}
+	if strings.Join(ids, ",") != "3,4,6" {
+		t.Error("Unexpected events", ids)
+	}
+	// a new client doesn't get the history
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+	fresh, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Body.Close()
+	r.Projects[1].publish(EventBuildStarted, nil)
+	reader = bufio.NewReader(fresh.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "id: ") {
+			if id := strings.TrimSpace(line[4:]); id != "7" {
+				t.Error("Expected only the new events instead of", id)
+			}
+			break
+		}
+	}
+}
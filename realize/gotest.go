@@ -0,0 +1,104 @@
+package realize
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// go test actions of a completed test or package
+const (
+	TestPass = "pass"
+	TestFail = "fail"
+	TestSkip = "skip"
+)
+
+// TestResult is the result of a package or of a single test, a package result has no test name
+type TestResult struct {
+	Time    time.Time     `json:"time"`
+	Package string        `json:"package"`
+	Test    string        `json:"test,omitempty"`
+	Action  string        `json:"action"`
+	Elapsed time.Duration `json:"elapsed"`
+	Output  string        `json:"output,omitempty"`
+}
+
+// TestEvent is a line of the go test -json stream
+type testEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// parseTests reads a go test -json stream, returns the results in order of completion and the plain text output
+func parseTests(r io.Reader) (results []TestResult, text string) {
+	var plain strings.Builder
+	outputs := make(map[string]*strings.Builder)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Action == "" {
+			// not a test event, as the build errors of older go versions
+			plain.WriteString(scanner.Text() + "\n")
+			continue
+		}
+		key := e.Package + "/" + e.Test
+		switch e.Action {
+		case "output", "build-output":
+			plain.WriteString(e.Output)
+			if outputs[key] == nil {
+				outputs[key] = &strings.Builder{}
+			}
+			outputs[key].WriteString(e.Output)
+		case TestPass, TestFail, TestSkip:
+			result := TestResult{
+				Time:    e.Time,
+				Package: e.Package,
+				Test:    e.Test,
+				Action:  e.Action,
+				Elapsed: time.Duration(e.Elapsed * float64(time.Second)),
+			}
+			if o, ok := outputs[key]; ok {
+				result.Output = o.String()
+				delete(outputs, key)
+			}
+			results = append(results, result)
+		}
+	}
+	return results, plain.String()
+}
+
+// testFailures returns the output of the failed tests and packages
+func testFailures(results []TestResult) string {
+	var failures strings.Builder
+	for _, r := range results {
+		if r.Action == TestFail {
+			failures.WriteString(r.Output)
+		}
+	}
+	return failures.String()
+}
+
+// testSummary counts the passed, failed and skipped tests of a package
+func testSummary(results []TestResult, pkg string) (pass, fail, skip int) {
+	for _, r := range results {
+		if r.Package != pkg || r.Test == "" {
+			continue
+		}
+		switch r.Action {
+		case TestPass:
+			pass++
+		case TestFail:
+			fail++
+		case TestSkip:
+			skip++
+		}
+	}
+	return
+}
@@ -0,0 +1,46 @@
+package realize
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTests(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"Action":"run","Package":"m/a","Test":"TestA"}`,
+		`{"Action":"output","Package":"m/a","Test":"TestA","Output":"=== RUN   TestA\n"}`,
+		`{"Action":"output","Package":"m/a","Test":"TestA","Output":"    a_test.go:9: wrong value\n"}`,
+		`{"Action":"fail","Package":"m/a","Test":"TestA","Elapsed":0.5}`,
+		`{"Action":"run","Package":"m/a","Test":"TestB"}`,
+		`{"Action":"skip","Package":"m/a","Test":"TestB","Elapsed":0}`,
+		`{"Action":"run","Package":"m/a","Test":"TestC"}`,
+		`{"Action":"pass","Package":"m/a","Test":"TestC","Elapsed":0.01}`,
+		`{"Action":"output","Package":"m/a","Output":"FAIL\n"}`,
+		`{"Action":"fail","Package":"m/a","Elapsed":1.25}`,
+		`# m/b`,
+	}, "\n")
+	results, text := parseTests(strings.NewReader(stream))
+	if len(results) != 4 {
+		t.Fatal("Expected 4 results instead", len(results))
+	}
+	if results[0].Test != "TestA" || results[0].Action != TestFail || results[0].Elapsed != 500*time.Millisecond {
+		t.Error("Unexpected result", results[0])
+	}
+	if !strings.Contains(results[0].Output, "wrong value") {
+		t.Error("Expected test output", results[0].Output)
+	}
+	if results[3].Test != "" || results[3].Output != "FAIL\n" {
+		t.Error("Unexpected package result", results[3])
+	}
+	if !strings.Contains(text, "=== RUN   TestA") || !strings.Contains(text, "# m/b") {
+		t.Error("Unexpected plain output", text)
+	}
+	pass, fail, skip := testSummary(results, "m/a")
+	if pass != 1 || fail != 1 || skip != 1 {
+		t.Error("Unexpected summary", pass, fail, skip)
+	}
+	if failures := testFailures(results); !strings.Contains(failures, "wrong value") || !strings.Contains(failures, "FAIL") {
+		t.Error("Unexpected failures", failures)
+	}
+}
@@ -0,0 +1,119 @@
+package realize
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ScopeAffected runs a per package tool on the changed packages and on every package depending on them
+const ScopeAffected = "affected"
+
+// Graph is the import graph of the packages of a module
+type graph struct {
+	dir       string
+	cmd       []string
+	env       []string
+	stale     bool
+	imports   map[string]map[string]bool
+	importers map[string][]string
+}
+
+// Load the packages of the module by go list
+func (g *graph) load() error {
+	var stdout, stderr bytes.Buffer
+	list := g.cmd
+	if len(list) == 0 {
+		list = []string{"go", "list"}
+	}
+	args := append(list[1:len(list):len(list)], "-e", "-f", "{{.Dir}}\t{{.ImportPath}}\t{{join .Imports \" \"}} {{join .TestImports \" \"}} {{join .XTestImports \" \"}}", "./...")
+	cmd := exec.Command(list[0], args...)
+	cmd.Dir = g.dir
+	cmd.Env = g.env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.New(stderr.String() + err.Error())
+	}
+	dirs := make(map[string]string)
+	g.imports = make(map[string]map[string]bool)
+	g.importers = make(map[string][]string)
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 || fields[0] == "" {
+			continue
+		}
+		dirs[fields[1]] = fields[0]
+		g.imports[fields[0]] = make(map[string]bool)
+		for _, i := range strings.Fields(fields[2]) {
+			g.imports[fields[0]][i] = true
+		}
+	}
+	for dir, imports := range g.imports {
+		for i := range imports {
+			if d, ok := dirs[i]; ok && d != dir {
+				g.importers[d] = append(g.importers[d], dir)
+			}
+		}
+	}
+	for dir := range g.importers {
+		sort.Strings(g.importers[dir])
+	}
+	g.stale = false
+	return nil
+}
+
+// Outdated check if the changed paths modify the graph, go.mod or new imports
+func (g *graph) outdated(paths []string) bool {
+	if g.stale {
+		return true
+	}
+	for _, path := range paths {
+		if filepath.Base(path) == "go.mod" {
+			return true
+		}
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			// removed or not yet valid files
+			continue
+		}
+		known, ok := g.imports[filepath.Dir(path)]
+		if !ok {
+			return true
+		}
+		for _, i := range f.Imports {
+			if v, err := strconv.Unquote(i.Path.Value); err == nil && !known[v] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Affected returns the given packages followed by every package depending on them
+func (g *graph) affected(packages []string) (result []string) {
+	seen := make(map[string]bool)
+	queue := append([]string{}, packages...)
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		result = append(result, dir)
+		queue = append(queue, g.importers[dir]...)
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGraph_Affected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graph_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.14\n",
+		"a/a.go":      "package a\n",
+		"b/b.go":      "package b\n\nimport _ \"example.com/m/a\"\n",
+		"c/c_test.go": "package c\n\nimport _ \"example.com/m/b\"\n",
+		"d/d.go":      "package d\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), Permission)
+		if err := ioutil.WriteFile(path, []byte(content), Permission); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// resolve symlinked temp folders as go list does
+	dir, _ = filepath.EvalSymlinks(dir)
+	g := graph{dir: dir, stale: true}
+	if !g.outdated(nil) {
+		t.Error("Expected a stale graph")
+	}
+	if err := g.load(); err != nil {
+		t.Fatal(err)
+	}
+	result := g.affected([]string{filepath.Join(dir, "a")})
+	expected := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")}
+	if len(result) != len(expected) {
+		t.Fatal("Unexpected packages", result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Error("Unexpected package", result[i], "instead", expected[i])
+		}
+	}
+	if g.outdated([]string{filepath.Join(dir, "b", "b.go")}) {
+		t.Error("Unexpected outdated graph")
+	}
+	ioutil.WriteFile(filepath.Join(dir, "d", "d.go"), []byte("package d\n\nimport _ \"example.com/m/a\"\n"), Permission)
+	if !g.outdated([]string{filepath.Join(dir, "d", "d.go")}) {
+		t.Error("Expected outdated graph after a new import")
+	}
+	if !g.outdated([]string{filepath.Join(dir, "go.mod")}) {
+		t.Error("Expected outdated graph after a go.mod change")
+	}
+}
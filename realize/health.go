@@ -0,0 +1,155 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// default health check values
+const (
+	HealthInterval = 250 * time.Millisecond
+	HealthTimeout  = 30 * time.Second
+)
+
+var errHealthStopped = errors.New("health check stopped")
+
+// Healthcheck polls a tcp address, an http url or a command until the run process is ready
+type Healthcheck struct {
+	TCP      string        `yaml:"tcp,omitempty" json:"tcp,omitempty"`
+	HTTP     string        `yaml:"http,omitempty" json:"http,omitempty"`
+	Status   int           `yaml:"status,omitempty" json:"status,omitempty"`
+	Command  string        `yaml:"command,omitempty" json:"command,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// Readiness is closed when the health check of a run process ends
+type readiness struct {
+	done   chan struct{}
+	err    error
+	exited chan struct{}
+	once   sync.Once
+}
+
+func newReadiness() *readiness {
+	return &readiness{done: make(chan struct{}), exited: make(chan struct{})}
+}
+
+// exit is called when the run process has exited for good, the health check stops polling
+func (r *readiness) exit() {
+	r.once.Do(func() { close(r.exited) })
+}
+
+// end the health check with its result
+func (r *readiness) end(err error) {
+	r.err = err
+	close(r.done)
+}
+
+// wait the end of the health check, returns false if the process isn't ready
+func (r *readiness) wait(stop <-chan bool) bool {
+	select {
+	case <-stop:
+		return false
+	case <-r.done:
+		return r.err == nil
+	}
+}
+
+// Check probes once the run process within the timeout, a command runs with the env if not nil
+func (h *Healthcheck) check(dir string, env []string, timeout time.Duration) error {
+	switch {
+	case h.TCP != "":
+		conn, err := net.DialTimeout("tcp", h.TCP, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case h.HTTP != "":
+		client := http.Client{Timeout: timeout}
+		resp, err := client.Get(h.HTTP)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		status := h.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if resp.StatusCode != status {
+			return errors.New("unexpected status " + strconv.Itoa(resp.StatusCode))
+		}
+		return nil
+	case h.Command != "":
+		args, err := h.args()
+		if err != nil {
+			return err
+		}
+		// a hanging command is killed as a slow connection
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Env = env
+		return cmd.Run()
+	}
+	return nil
+}
+
+// args splits the command as the scripts
+func (h *Healthcheck) args() ([]string, error) {
+	return (&Command{Cmd: h.Command}).args()
+}
+
+// Wait polls the run process until it's ready, the timeout expires, the process exits or stop is closed,
+// each probe can take the time left before the timeout
+func (h *Healthcheck) wait(dir string, env []string, stop <-chan bool, exited <-chan struct{}) error {
+	if h.Command != "" {
+		// an invalid command is never ready
+		if _, err := h.args(); err != nil {
+			return err
+		}
+	}
+	deadline := time.Now().Add(h.timeout())
+	var err error
+	for {
+		left := time.Until(deadline)
+		if err != nil && left <= 0 {
+			return errors.New("not ready after " + h.timeout().String() + ": " + err.Error())
+		}
+		if err = h.check(dir, env, left); err == nil {
+			return nil
+		}
+		delay := h.interval()
+		if left := time.Until(deadline); left < delay {
+			delay = left
+		}
+		select {
+		case <-stop:
+			return errHealthStopped
+		case <-exited:
+			return errors.New("process exited before being ready: " + err.Error())
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (h *Healthcheck) interval() time.Duration {
+	if h.Interval > 0 {
+		return h.Interval
+	}
+	return HealthInterval
+}
+
+func (h *Healthcheck) timeout() time.Duration {
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+	return HealthTimeout
+}
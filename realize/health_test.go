@@ -0,0 +1,53 @@
+package realize
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthcheck_Wait(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	h := Healthcheck{TCP: ln.Addr().String(), Interval: 10 * time.Millisecond, Timeout: time.Second}
+	if err := h.wait("", nil, nil, nil); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	h = Healthcheck{HTTP: srv.URL, Status: http.StatusNoContent, Interval: 10 * time.Millisecond, Timeout: time.Second}
+	if err := h.wait("", nil, nil, nil); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	h.Status = 0
+	h.Timeout = 50 * time.Millisecond
+	if err := h.wait("", nil, nil, nil); err == nil {
+		t.Error("Expected error for an unexpected status")
+	}
+	stop := make(chan bool)
+	close(stop)
+	h = Healthcheck{TCP: "127.0.0.1:1", Interval: 10 * time.Millisecond}
+	if err := h.wait("", nil, stop, nil); err != errHealthStopped {
+		t.Error("Expected stopped health check instead", err)
+	}
+}
+
+func TestReadiness_Wait(t *testing.T) {
+	ready := newReadiness()
+	go ready.end(nil)
+	if !ready.wait(nil) {
+		t.Error("Expected ready")
+	}
+	ready = newReadiness()
+	ready.end(errHealthStopped)
+	if ready.wait(nil) {
+		t.Error("Unexpected ready")
+	}
+}
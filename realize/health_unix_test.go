@@ -0,0 +1,41 @@
+// +build !windows
+
+package realize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthcheck_Command(t *testing.T) {
+	h := Healthcheck{Command: `sh -c "exit 0"`, Interval: 10 * time.Millisecond, Timeout: time.Second}
+	if err := h.wait("", nil, nil, nil); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	h = Healthcheck{Command: "  ", Timeout: time.Minute}
+	if err := h.wait("", nil, nil, nil); err == nil {
+		t.Error("Expected an empty command error")
+	}
+	// a hanging command doesn't block past the timeout
+	h = Healthcheck{Command: "sleep 10", Interval: 50 * time.Millisecond, Timeout: 200 * time.Millisecond}
+	begin := time.Now()
+	if err := h.wait("", nil, nil, nil); err == nil {
+		t.Error("Expected a not ready error")
+	}
+	if time.Since(begin) > 2*time.Second {
+		t.Error("The health check waited the hanging command")
+	}
+	// a probe can take longer than the interval
+	h = Healthcheck{Command: "sleep 0.4", Timeout: 5 * time.Second}
+	if err := h.wait("", nil, nil, nil); err != nil {
+		t.Error("Expected a slow command to pass", err)
+	}
+	// the polling ends with the run process
+	exited := make(chan struct{})
+	close(exited)
+	h = Healthcheck{Command: "false", Timeout: time.Minute}
+	begin = time.Now()
+	if err := h.wait("", nil, nil, exited); err == nil || time.Since(begin) > 2*time.Second {
+		t.Error("Expected the health check ended by the process exit", err)
+	}
+}
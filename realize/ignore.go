@@ -0,0 +1,154 @@
+package realize
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ignore files read when the gitignore option is enabled
+const (
+	GitIgnore     = ".gitignore"
+	RealizeIgnore = ".realizeignore"
+)
+
+// Gitignore holds the rules of every ignore file found in a project tree
+type gitignore struct {
+	mu    sync.RWMutex
+	rules []rule
+}
+
+// Rule is a single pattern of an ignore file
+type rule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dir      bool
+	anchored bool
+}
+
+// isIgnoreFile check if a path is one of the supported ignore files
+func isIgnoreFile(path string) bool {
+	name := filepath.Base(path)
+	return name == GitIgnore || name == RealizeIgnore
+}
+
+// Load (or reload) the ignore files of a directory
+func (g *gitignore) load(dir string) {
+	var rules []rule
+	for _, name := range []string{GitIgnore, RealizeIgnore} {
+		rules = append(rules, parse(dir, filepath.Join(dir, name))...)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	current := g.rules[:0]
+	for _, r := range g.rules {
+		if r.base != dir {
+			current = append(current, r)
+		}
+	}
+	g.rules = append(current, rules...)
+	// deeper files take precedence over their parents
+	sort.SliceStable(g.rules, func(i, j int) bool {
+		return depth(g.rules[i].base) < depth(g.rules[j].base)
+	})
+}
+
+// Parents loads the ignore files of the directories above a path, up to the repository root
+func (g *gitignore) parents(path string) {
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		g.load(dirs[i])
+	}
+}
+
+// Match check if a path, or one of its parents, is ignored
+func (g *gitignore) match(path string, dir bool) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.rules) == 0 {
+		return false
+	}
+	// a path inside an ignored directory can't be included again
+	for parent := filepath.Dir(path); parent != filepath.Dir(parent); parent = filepath.Dir(parent) {
+		if g.ignored(parent, true) {
+			return true
+		}
+	}
+	return g.ignored(path, dir)
+}
+
+// Ignored returns the result of the last rule matching a path
+func (g *gitignore) ignored(path string, dir bool) (result bool) {
+	for _, r := range g.rules {
+		if r.dir && !dir {
+			continue
+		}
+		rel, err := filepath.Rel(r.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if !r.anchored {
+			// patterns without a slash match at any level
+			rel = filepath.ToSlash(filepath.Base(rel))
+		}
+		if glob(r.pattern, rel) {
+			result = !r.negate
+		}
+	}
+	return
+}
+
+// Parse an ignore file, a missing file has no rules
+func parse(base string, file string) (rules []rule) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		// trailing spaces are ignored unless escaped
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+			line = line[:len(line)-1]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := rule{base: base}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dir = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// Depth of a path
+func depth(path string) int {
+	return strings.Count(filepath.ToSlash(path), "/")
+}
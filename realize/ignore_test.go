@@ -0,0 +1,87 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignore_Match(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignore_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		GitIgnore:                           "# comment\n*.log\n!keep.log\nbuild/\n/root.go\ndocs/**/*.md\n",
+		filepath.Join("sub", GitIgnore):     "!debug.log\ngen_*.go\n",
+		filepath.Join("sub", RealizeIgnore): "local.go\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), Permission)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), Permission); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g := gitignore{}
+	g.load(dir)
+	g.load(filepath.Join(dir, "sub"))
+	data := map[string]bool{
+		"app.log":            true,
+		"keep.log":           false,
+		"sub/app.log":        true,
+		"sub/debug.log":      false,
+		"build/main.go":      true,
+		"root.go":            true,
+		"sub/root.go":        false,
+		"docs/a/b/readme.md": true,
+		"docs/readme.txt":    false,
+		"sub/gen_types.go":   true,
+		"gen_types.go":       false,
+		"sub/local.go":       true,
+		"main.go":            false,
+	}
+	for path, expected := range data {
+		if g.match(filepath.Join(dir, filepath.FromSlash(path)), false) != expected {
+			t.Error("Unexpected result", path, "expected", expected)
+		}
+	}
+	if !g.match(filepath.Join(dir, "build"), true) {
+		t.Error("Expected build folder ignored")
+	}
+	if g.match(filepath.Join(dir, "build"), false) {
+		t.Error("Unexpected build file ignored")
+	}
+}
+
+func TestIsIgnoreFile(t *testing.T) {
+	if !isIgnoreFile("/a/"+GitIgnore) || !isIgnoreFile(RealizeIgnore) || isIgnoreFile("/a/main.go") {
+		t.Error("Unexpected result")
+	}
+}
+
+func TestProject_WalkGit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "walk_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	git := filepath.Join(dir, ".git")
+	os.MkdirAll(filepath.Join(git, "objects"), Permission)
+	info, err := os.Stat(git)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Project{Path: dir, Watcher: Watch{Gitignore: true}, ignore: &gitignore{}}
+	if p.walk(git, info, nil) != filepath.SkipDir {
+		t.Error("Expected .git folder skipped")
+	}
+	if !p.shouldIgnore(filepath.Join(git, "objects", "pack")) {
+		t.Error("Expected .git content ignored")
+	}
+	p.ignore = nil
+	if p.shouldIgnore(git) {
+		t.Error("Unexpected .git folder ignored without gitignore")
+	}
+}
@@ -0,0 +1,155 @@
+package realize
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// log file formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// layout of the timestamp in the name of a backup
+const backupLayout = "20060102T150405.000"
+
+// logEntry is a line of a json log file
+type logEntry struct {
+	Project string    `json:"project"`
+	Stream  string    `json:"stream"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Path    string    `json:"path"`
+	Text    string    `json:"text"`
+}
+
+// logFile is a long lived log writer rotated by size and age
+type logFile struct {
+	mu      sync.Mutex
+	name    string
+	res     Resource
+	file    *os.File
+	size    int64
+	created time.Time
+}
+
+// openLog opens or creates a log file in append mode
+func openLog(name string, res Resource) (*logFile, error) {
+	l := &logFile{name: name, res: res}
+	return l, l.open()
+}
+
+func (l *logFile) open() error {
+	file, err := os.OpenFile(l.name, os.O_APPEND|os.O_WRONLY|os.O_CREATE, Permission)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.size, l.created = file, info.Size(), time.Now()
+	return nil
+}
+
+// Write appends to the log file, rotating it first if it's over the size or the age limit
+func (l *logFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size > 0 && (l.res.MaxSize > 0 && l.size+int64(len(p)) > l.res.MaxSize ||
+		l.res.MaxAge > 0 && time.Since(l.created) > l.res.MaxAge) {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// Close the log file
+func (l *logFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// rotate compresses the current file as a backup and opens a new one
+func (l *logFile) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	backup := l.backup(time.Now())
+	if err := os.Rename(l.name, backup); err != nil {
+		return err
+	}
+	if err := compress(backup); err != nil {
+		return err
+	}
+	if err := l.prune(); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// backup returns the name of a backup, the timestamp is placed before the extension
+func (l *logFile) backup(t time.Time) string {
+	ext := filepath.Ext(l.name)
+	return strings.TrimSuffix(l.name, ext) + "-" + t.Format(backupLayout) + ext
+}
+
+// prune removes the oldest backups over the max number
+func (l *logFile) prune() error {
+	if l.res.Backups <= 0 {
+		return nil
+	}
+	ext := filepath.Ext(l.name)
+	backups, err := filepath.Glob(strings.TrimSuffix(l.name, ext) + "-*" + ext + ".gz")
+	if err != nil {
+		return err
+	}
+	// the timestamp layout sorts by date
+	sort.Strings(backups)
+	for len(backups) > l.res.Backups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// compress gzips a file and removes the original one
+func compress(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, Permission)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,77 @@
+package realize
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogFile_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "out.log")
+	l, err := openLog(name, Resource{MaxSize: 10, Backups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		// backups are named by millisecond
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(name)
+	if err != nil || string(content) != "dddddd\n" {
+		t.Fatal("Unexpected current file", string(content), err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "out-*.log.gz"))
+	if len(backups) != 2 {
+		t.Fatal("Expected 2 backups instead", backups)
+	}
+	f, err := os.Open(backups[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := ioutil.ReadAll(gz); err != nil || string(content) != "cccccc\n" {
+		t.Error("Unexpected backup", string(content), err)
+	}
+}
+
+func TestLogFile_Age(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "out.log")
+	l, err := openLog(name, Resource{MaxAge: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Write([]byte("old\n"))
+	l.created = time.Now().Add(-time.Hour)
+	l.Write([]byte("new\n"))
+	if content, _ := ioutil.ReadFile(name); string(content) != "new\n" {
+		t.Error("Unexpected current file", string(content))
+	}
+	if backups, _ := filepath.Glob(filepath.Join(dir, "out-*.log.gz")); len(backups) != 1 || strings.Contains(backups[0], "out.log-") {
+		t.Error("Unexpected backups", backups)
+	}
+}
@@ -0,0 +1,145 @@
+package realize
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// StopTimeout is the default time given to the run process to exit before it is killed
+const StopTimeout = 5 * time.Second
+
+// stopSignal returns the signal used to stop the run process, interrupt by default
+func (t *Tool) stopSignal() (os.Signal, error) {
+	if t.StopSignal == "" {
+		return os.Interrupt, nil
+	}
+	name := strings.ToUpper(t.StopSignal)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	if sig, ok := stopSignals[name]; ok {
+		return sig, nil
+	}
+	return nil, errors.New("unsupported stop signal " + t.StopSignal)
+}
+
+// stopTimeout returns the time to wait for the run process before killing it
+func (t *Tool) stopTimeout() time.Duration {
+	if t.StopTimeout > 0 {
+		return t.StopTimeout
+	}
+	return StopTimeout
+}
+
+// terminate sends a signal to the process group and kills it if it doesn't exit before the timeout
+func terminate(cmd *exec.Cmd, exited <-chan struct{}, sig os.Signal, timeout time.Duration) error {
+	if err := signalGroup(cmd, sig); err != nil {
+		// signals aren't supported on every platform
+		killGroup(cmd)
+		<-exited
+		return nil
+	}
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(timeout):
+		killGroup(cmd)
+		<-exited
+		return errors.New("process killed after " + timeout.String())
+	}
+}
+
+// acquire waits the exit of the previous run process, false if stopped meanwhile
+func (p *Project) acquire(stop <-chan bool) bool {
+	if p.proc == nil {
+		return true
+	}
+	select {
+	case p.proc <- struct{}{}:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// release is called when the run process has exited
+func (p *Project) release() {
+	if p.proc != nil {
+		<-p.proc
+	}
+}
+
+// restart policies of the run process
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+// backoff delays between the restarts of the run process
+const (
+	Backoff    = time.Second
+	MaxBackoff = time.Minute
+)
+
+// number of stderr lines kept as crash reason
+const crashLines = 10
+
+// exitError is returned when the run process exits by itself
+type exitError struct {
+	state  *os.ProcessState
+	reason string
+}
+
+// Error returns the exit status of the process
+func (e *exitError) Error() string {
+	if e.state == nil {
+		return "process exited"
+	}
+	return "process exited with " + e.state.String()
+}
+
+// code returns the exit code of the process, -1 if killed by a signal
+func (e *exitError) code() int {
+	if e.state == nil {
+		return -1
+	}
+	return e.state.ExitCode()
+}
+
+// failed check if the process exited with an error
+func (e *exitError) failed() bool {
+	return e.state == nil || !e.state.Success()
+}
+
+// restart check the policy for a new attempt after an exit
+func (t *Tool) restart(exit *exitError, attempt int) bool {
+	if t.MaxRetries > 0 && attempt >= t.MaxRetries {
+		return false
+	}
+	switch t.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exit.failed()
+	}
+	return false
+}
+
+// backoff returns the delay before a restart, doubled on each attempt
+func (t *Tool) backoff(attempt int) time.Duration {
+	delay := Backoff
+	if t.Backoff > 0 {
+		delay = t.Backoff
+	}
+	for i := 0; i < attempt && delay < MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > MaxBackoff {
+		delay = MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,44 @@
+package realize
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTool_StopSignal(t *testing.T) {
+	tool := Tool{}
+	if sig, err := tool.stopSignal(); err != nil || sig != os.Interrupt {
+		t.Error("Expected interrupt as default signal", sig, err)
+	}
+	for _, name := range []string{"SIGTERM", "sigquit", "INT"} {
+		tool.StopSignal = name
+		if _, err := tool.stopSignal(); err != nil {
+			t.Error("Unexpected error", name, err)
+		}
+	}
+	tool.StopSignal = "SIGFOO"
+	if _, err := tool.stopSignal(); err == nil {
+		t.Error("Expected error for an unsupported signal")
+	}
+	if tool.stopTimeout() != StopTimeout {
+		t.Error("Expected default timeout")
+	}
+	tool.StopTimeout = time.Second
+	if tool.stopTimeout() != time.Second {
+		t.Error("Expected custom timeout")
+	}
+}
+
+func TestTool_Backoff(t *testing.T) {
+	tool := Tool{Backoff: 100 * time.Millisecond}
+	delays := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}
+	for i, expected := range delays {
+		if d := tool.backoff(i); d != expected {
+			t.Error("Unexpected delay", d, "instead", expected)
+		}
+	}
+	if d := tool.backoff(100); d != MaxBackoff {
+		t.Error("Expected max delay instead", d)
+	}
+}
@@ -0,0 +1,120 @@
+// +build !windows
+
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func start(t *testing.T, script string) (*exec.Cmd, chan struct{}) {
+	cmd := exec.Command("sh", "-c", script)
+	setGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Process.Wait()
+		close(exited)
+	}()
+	// give the shell the time to set its traps
+	time.Sleep(100 * time.Millisecond)
+	return cmd, exited
+}
+
+func TestTerminate(t *testing.T) {
+	cmd, exited := start(t, "sleep 10")
+	if err := terminate(cmd, exited, syscall.SIGTERM, time.Second); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	dir, err := ioutil.TempDir("", "process_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "alive")
+	cmd, exited = start(t, "trap '' TERM; (sleep 0.5; touch "+marker+") & wait")
+	begin := time.Now()
+	if err := terminate(cmd, exited, syscall.SIGTERM, 200*time.Millisecond); err == nil {
+		t.Error("Expected a killed process")
+	}
+	if time.Since(begin) > 5*time.Second {
+		t.Error("Process not killed after the timeout")
+	}
+	// the child process has been killed with the whole group
+	time.Sleep(time.Second)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected no processes left in the group")
+	}
+}
+
+func TestTool_Restart(t *testing.T) {
+	success := exec.Command("true")
+	failure := exec.Command("false")
+	success.Run()
+	failure.Run()
+	ok, ko := &exitError{state: success.ProcessState}, &exitError{state: failure.ProcessState}
+	tool := Tool{}
+	if tool.restart(ko, 0) {
+		t.Error("Unexpected restart without a policy")
+	}
+	tool.Restart = RestartOnFailure
+	if !tool.restart(ko, 0) || tool.restart(ok, 0) {
+		t.Error("Expected restart only on failure")
+	}
+	tool.Restart = RestartAlways
+	tool.MaxRetries = 2
+	if !tool.restart(ok, 1) || tool.restart(ko, 2) {
+		t.Error("Expected restart until max retries")
+	}
+	if !strings.Contains(ko.Error(), "exit status 1") {
+		t.Error("Unexpected exit error", ko.Error())
+	}
+}
+
+func TestProject_WatchExit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "process_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	started, interrupted := filepath.Join(dir, "started"), filepath.Join(dir, "interrupted")
+	script := filepath.Join(dir, "app.sh")
+	app := "#!/bin/sh\ntrap 'sleep 0.3; touch " + interrupted + "; exit 0' INT\ntouch " + started + "\nwhile :; do sleep 0.1; done\n"
+	if err := ioutil.WriteFile(script, []byte(app), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{
+		parent: &r,
+		Path:   dir,
+		exit:   make(chan os.Signal, 1),
+		Tools: Tools{
+			Install: Tool{Status: true, Method: "true"},
+			Run:     Tool{Status: true, Method: script, StopSignal: "SIGTERM"},
+		},
+	})
+	wg.Add(1)
+	go r.Projects[0].Watch(&wg)
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	close(r.Projects[0].exit)
+	wg.Wait()
+	// the process got the interrupt and exited before the project
+	if _, err := os.Stat(interrupted); err != nil {
+		t.Error("Expected the run process interrupted and exited")
+	}
+}
@@ -0,0 +1,28 @@
+// +build windows
+
+package realize
+
+import (
+	"os"
+	"os/exec"
+)
+
+// signals supported as stop signal, windows can only interrupt or kill a process
+var stopSignals = map[string]os.Signal{
+	"SIGINT":  os.Interrupt,
+	"SIGTERM": os.Interrupt,
+	"SIGQUIT": os.Interrupt,
+}
+
+// setGroup is not supported on windows
+func setGroup(cmd *exec.Cmd) {}
+
+// signalGroup sends a signal to the command process
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+// killGroup kills the command process
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
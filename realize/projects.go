@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -25,13 +27,25 @@ var (
 	out BufferOut
 )
 
+// Debounce is the default quiet period used to gather file events before a reload
+const Debounce = 300 * time.Millisecond
+
+// failure policies of a command
+const (
+	FailureContinue = "continue"
+	FailureAbort    = "abort"
+	FailureSkipRun  = "skip-run"
+)
+
 // Watch info
 type Watch struct {
-	Exts    []string  `yaml:"extensions" json:"extensions"`
-	Paths   []string  `yaml:"paths" json:"paths"`
-	Scripts []Command `yaml:"scripts,omitempty" json:"scripts,omitempty"`
-	Hidden  bool      `yaml:"hidden,omitempty" json:"hidden,omitempty"`
-	Ignore  []string  `yaml:"ignored_paths,omitempty" json:"ignored_paths,omitempty"`
+	Exts      []string      `yaml:"extensions" json:"extensions"`
+	Paths     []string      `yaml:"paths" json:"paths"`
+	Scripts   []Command     `yaml:"scripts,omitempty" json:"scripts,omitempty"`
+	Hidden    bool          `yaml:"hidden,omitempty" json:"hidden,omitempty"`
+	Ignore    []string      `yaml:"ignored_paths,omitempty" json:"ignored_paths,omitempty"`
+	Debounce  time.Duration `yaml:"debounce,omitempty" json:"debounce,omitempty"`
+	Gitignore bool          `yaml:"gitignore,omitempty" json:"gitignore,omitempty"`
 }
 
 type Ignore struct {
@@ -41,11 +55,20 @@ type Ignore struct {
 
 // Command fields
 type Command struct {
-	Cmd    string `yaml:"command" json:"command"`
-	Type   string `yaml:"type" json:"type"`
-	Path   string `yaml:"path,omitempty" json:"path,omitempty"`
-	Global bool   `yaml:"global,omitempty" json:"global,omitempty"`
-	Output bool   `yaml:"output,omitempty" json:"output,omitempty"`
+	Cmd        string            `yaml:"command" json:"command"`
+	Type       string            `yaml:"type" json:"type"`
+	Path       string            `yaml:"path,omitempty" json:"path,omitempty"`
+	Global     bool              `yaml:"global,omitempty" json:"global,omitempty"`
+	Output     bool              `yaml:"output,omitempty" json:"output,omitempty"`
+	Ready      bool              `yaml:"ready,omitempty" json:"ready,omitempty"`
+	Shell      string            `yaml:"shell,omitempty" json:"shell,omitempty"`
+	Timeout    time.Duration     `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Retries    int               `yaml:"retries,omitempty" json:"retries,omitempty"`
+	OnFailure  string            `yaml:"on_failure,omitempty" json:"on_failure,omitempty"`
+	Env        map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	Background bool              `yaml:"background,omitempty" json:"background,omitempty"`
+	On         []string          `yaml:"on,omitempty" json:"on,omitempty"`
+	expanded   string
 }
 
 // Project info
@@ -54,7 +77,19 @@ type Project struct {
 	watcher    FileWatcher
 	stop       chan bool
 	exit       chan os.Signal
+	proc       chan struct{}
+	interrupt  chan struct{}
 	paths      []string
+	ignore     *gitignore
+	resolved   atomic.Value
+	graph      *graph
+	state      *state
+	writes     *writes
+	global     string
+	control    chan string
+	cascade    chan struct{}
+	done       chan struct{}
+	up         *latch
 	last       last
 	files      int64
 	folders    int64
@@ -62,14 +97,17 @@ type Project struct {
 	Name       string            `yaml:"name" json:"name"`
 	Path       string            `yaml:"path" json:"path"`
 	Env        map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	EnvFile    []string          `yaml:"env_file,omitempty" json:"env_file,omitempty"`
 	Args       []string          `yaml:"args,omitempty" json:"args,omitempty"`
+	DependsOn  []string          `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
 	Tools      Tools             `yaml:"commands" json:"commands"`
 	Watcher    Watch             `yaml:"watcher" json:"watcher"`
+	Proxy      *Proxy            `yaml:"proxy,omitempty" json:"proxy,omitempty"`
 	Buffer     Buffer            `yaml:"-" json:"buffer"`
 	ErrPattern string            `yaml:"pattern,omitempty" json:"pattern,omitempty"`
 }
 
-// Last is used to save info about last file changed
+// Last is used to save info about last file event
 type last struct {
 	file string
 	time time.Time
@@ -77,26 +115,32 @@ type last struct {
 
 // Response exec
 type Response struct {
-	Name string
-	Out  string
-	Err  error
+	Name        string
+	Path        string
+	Out         string
+	Err         error
+	Code        int
+	Tests       []TestResult
+	Diagnostics []Diagnostic
 }
 
-// Buffer define an array buffer for each log files
+// Buffer define a ring buffer for each log files
 type Buffer struct {
-	StdOut []BufferOut `json:"stdOut"`
-	StdLog []BufferOut `json:"stdLog"`
-	StdErr []BufferOut `json:"stdErr"`
+	StdOut  *Ring     `json:"stdOut"`
+	StdLog  *Ring     `json:"stdLog"`
+	StdErr  *Ring     `json:"stdErr"`
+	StdTest *TestRing `json:"stdTest"`
 }
 
 // BufferOut is used for exchange information between "realize cli" and "web realize"
 type BufferOut struct {
-	Time   time.Time `json:"time"`
-	Text   string    `json:"text"`
-	Path   string    `json:"path"`
-	Type   string    `json:"type"`
-	Stream string    `json:"stream"`
-	Errors []string  `json:"errors"`
+	Time        time.Time    `json:"time"`
+	Text        string       `json:"text"`
+	Path        string       `json:"path"`
+	Type        string       `json:"type"`
+	Stream      string       `json:"stream"`
+	Errors      []string     `json:"errors"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
 }
 
 // After stop watcher
@@ -105,7 +149,7 @@ func (p *Project) After() {
 		p.parent.After(Context{Project: p})
 		return
 	}
-	p.cmd(nil, "after", true)
+	p.cmd(nil, "after", true, nil, nil)
 }
 
 // Before start watcher
@@ -121,18 +165,33 @@ func (p *Project) Before() {
 
 	// setup go tools
 	p.Tools.Setup()
-	// global commands before
-	p.cmd(p.stop, "before", true)
+	// expand the environment references
+	if err := p.environment(); err != nil {
+		p.Err(err)
+	}
+	// global commands before, their failure applies to the first reload
+	p.global = p.cmd(p.stop, "before", true, nil, nil)
+	// ignore files are loaded while walking the tree
+	if p.Watcher.Gitignore {
+		p.ignore = &gitignore{}
+	}
 	// indexing files and dirs
-	for _, dir := range p.Watcher.Paths {
-		base, _ := filepath.Abs(p.Path)
+	for _, dir := range p.Watcher.roots() {
+		base, _ := filepath.Abs(p.dir())
 		base = filepath.Join(base, dir)
+		if p.ignore != nil {
+			p.ignore.parents(base)
+		}
 		if _, err := os.Stat(base); err == nil {
 			if err := filepath.Walk(base, p.walk); err != nil {
 				p.Err(err)
 			}
 		}
 	}
+	// env files are watched even outside the paths
+	for _, name := range p.envFiles() {
+		p.watcher.Add(name)
+	}
 	// start message
 	msg = fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Watching"), Magenta.Bold(p.files), "file/s", Magenta.Bold(p.folders), "folder/s")
 	out = BufferOut{Time: time.Now(), Text: "Watching " + strconv.FormatInt(p.files, 10) + " files/s " + strconv.FormatInt(p.folders, 10) + " folder/s"}
@@ -170,13 +229,31 @@ func (p *Project) Change(event fsnotify.Event) {
 }
 
 // Reload launches the toolchain run, build, install
-func (p *Project) Reload(path string, stop <-chan bool) {
+func (p *Project) Reload(events []fsnotify.Event, stop <-chan bool) {
+	p.reload(events, stop, "")
+}
+
+// reload with the failure of the global scripts, aborting or skipping the run as the failure of a script
+func (p *Project) reload(events []fsnotify.Event, stop <-chan bool, global string) {
+	paths := names(events)
 	if p.parent.Reload != nil {
-		p.parent.Reload(Context{Project: p, Watcher: p.watcher, Path: path, Stop: stop})
+		ctx := Context{Project: p, Watcher: p.watcher, Paths: paths, Events: events, Stop: stop}
+		if len(paths) > 0 {
+			ctx.Path = paths[len(paths)-1]
+		}
+		p.parent.Reload(ctx)
 		return
 	}
+	// the output of the triggered scripts doesn't trigger them again
+	triggers := p.writes.triggers(paths)
 	var done bool
 	var install, build Response
+	var ready *readiness
+	// the config expanded when the reload started
+	x := p.expanded()
+	// hold the proxy requests until the new process is ready
+	p.Proxy.hold()
+	p.state.set(StateBuilding)
 	go func() {
 		for {
 			select {
@@ -190,20 +267,29 @@ func (p *Project) Reload(path string, stop <-chan bool) {
 		return
 	}
 	// before command
-	p.cmd(stop, "before", false)
+	failure := p.cmd(stop, "before", false, nil, triggers)
 	if done {
 		return
 	}
+	if failure != FailureAbort && global != "" {
+		failure = global
+	}
+	if failure == FailureAbort {
+		msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular("Reload aborted by a failed command"))
+		out = BufferOut{Time: time.Now(), Text: "Reload aborted by a failed command", Type: "before"}
+		p.stamp("error", out, msg, "")
+		p.Proxy.release()
+		p.state.set(StateFailed)
+		return
+	}
 	// Go supported tools
-	if len(path) > 0 {
-		fi, err := os.Stat(path)
-		if filepath.Ext(path) == "" {
-			fi, err = os.Stat(path)
+	if len(paths) > 0 {
+		var affected []string
+		files, packages := targets(paths)
+		if p.Tools.scoped() {
+			affected = p.affected(paths, packages)
 		}
-		if err != nil {
-			p.Err(err)
-		}
-		p.tools(stop, path, fi)
+		p.tools(stop, files, packages, affected)
 	}
 	// Prevent fake events on polling startup
 	p.init = true
@@ -218,9 +304,13 @@ func (p *Project) Reload(path string, stop <-chan bool) {
 		msg = fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Install.name), "started")
 		out = BufferOut{Time: time.Now(), Text: p.Tools.Install.name + " started"}
 		p.stamp("log", out, msg, "")
+		p.publish(EventBuildStarted, map[string]string{"tool": p.Tools.Install.name})
 		start := time.Now()
-		install = p.Tools.Install.Compile(p.Path, stop)
+		tool := p.Tools.Install
+		tool.Args = x.tools[&p.Tools.Install]
+		install = tool.Compile(x.path, p.buildEnvs(tool.Env), stop)
 		install.print(start, p)
+		p.state.built(start, install)
 	}
 	if done {
 		return
@@ -229,14 +319,32 @@ func (p *Project) Reload(path string, stop <-chan bool) {
 		msg = fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Build.name), "started")
 		out = BufferOut{Time: time.Now(), Text: p.Tools.Build.name + " started"}
 		p.stamp("log", out, msg, "")
+		p.publish(EventBuildStarted, map[string]string{"tool": p.Tools.Build.name})
 		start := time.Now()
-		build = p.Tools.Build.Compile(p.Path, stop)
+		tool := p.Tools.Build
+		tool.Args = x.tools[&p.Tools.Build]
+		build = tool.Compile(x.path, p.buildEnvs(tool.Env), stop)
 		build.print(start, p)
+		p.state.built(start, build)
 	}
 	if done {
 		return
 	}
-	if install.Err == nil && build.Err == nil && p.Tools.Run.Status {
+	if install.Err == nil && build.Err == nil && p.Tools.Run.Status && failure != FailureSkipRun {
+		// the previous process can still hold its ports until it exits
+		if !p.acquire(stop) {
+			return
+		}
+		ready = newReadiness()
+		go p.healthcheck(ready, stop)
+		go func() {
+			if ready.wait(stop) {
+				p.started()
+				p.Proxy.reload(stop)
+			} else {
+				p.Proxy.release()
+			}
+		}()
 		result := make(chan Response)
 		go func() {
 			for {
@@ -248,49 +356,132 @@ func (p *Project) Reload(path string, stop <-chan bool) {
 						msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(r.Err))
 						out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: "Go Run"}
 						p.stamp("error", out, msg, "")
+						p.publish(EventRunOutput, map[string]string{"stream": "stderr", "text": r.Err.Error()})
 					}
 					if r.Out != "" {
 						msg := fmt.Sprintln(p.pname(p.Name, 3), ":", Blue.Regular(r.Out))
 						out := BufferOut{Time: time.Now(), Text: r.Out, Type: "Go Run"}
 						p.stamp("out", out, msg, "")
+						p.publish(EventRunOutput, map[string]string{"stream": "stdout", "text": r.Out})
 					}
 				}
 			}
 		}()
 		go func() {
-			log.Println(p.pname(p.Name, 1), ":", "Running..")
-			err := p.run(p.Path, result, stop)
-			if err != nil {
-				msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(err))
-				out := BufferOut{Time: time.Now(), Text: err.Error(), Type: "Go Run"}
-				p.stamp("error", out, msg, "")
+			defer p.release()
+			defer ready.exit()
+			for attempt := 0; ; attempt++ {
+				log.Println(p.pname(p.Name, 1), ":", "Running..")
+				p.state.set(StateRunning)
+				start := time.Now()
+				err := p.run(x, result, stop)
+				exit, exited := err.(*exitError)
+				switch {
+				case exited && exit.failed(), !exited && err != nil:
+					p.state.set(StateFailed)
+				case exited:
+					p.state.set(StateExited)
+				}
+				if exited {
+					p.publish(EventProcessExited, map[string]interface{}{"code": exit.code(), "status": exit.Error(), "reason": exit.reason})
+				}
+				if exited && !exit.failed() {
+					msg := fmt.Sprintln(p.pname(p.Name, 1), ":", "Go Run", exit.Error())
+					out := BufferOut{Time: time.Now(), Text: exit.Error(), Type: "Go Run"}
+					p.stamp("log", out, msg, "")
+				} else if err != nil {
+					msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(err))
+					out := BufferOut{Time: time.Now(), Text: err.Error(), Type: "Go Run"}
+					if exited {
+						out.Stream = exit.reason
+					}
+					p.stamp("error", out, msg, "")
+				}
+				if !exited {
+					return
+				}
+				// a process running for a while starts again from the first delay
+				if time.Since(start) > MaxBackoff {
+					attempt = 0
+				}
+				if !p.Tools.Run.restart(exit, attempt) {
+					return
+				}
+				delay := p.Tools.Run.backoff(attempt)
+				msg := fmt.Sprintln(p.pname(p.Name, 1), ":", "Go Run", "restart in", Magenta.Regular(delay))
+				out := BufferOut{Time: time.Now(), Text: "restart in " + delay.String(), Type: "Go Run"}
+				p.stamp("log", out, msg, "")
+				select {
+				case <-stop:
+					return
+				case <-time.After(delay):
+				}
 			}
 		}()
 	}
+	if ready == nil {
+		p.Proxy.release()
+		if install.Err != nil || build.Err != nil || failure != "" {
+			p.state.set(StateFailed)
+		} else {
+			p.state.set(StateIdle)
+			p.started()
+		}
+	}
 	if done {
 		return
 	}
-	p.cmd(stop, "after", false)
+	p.cmd(stop, "after", false, ready, triggers)
 }
 
 // Watch a project
 func (p *Project) Watch(wg *sync.WaitGroup) {
 	var err error
+	// events gathered during the debounce window
+	var pending []fsnotify.Event
+	var flush <-chan time.Time
+	// stopped by a control command
+	var paused bool
+	// an env file changed
+	var env bool
 	// change channel
 	p.stop = make(chan bool)
+	// one run process at a time
+	p.proc = make(chan struct{}, 1)
+	p.interrupt = make(chan struct{})
 	// init a new watcher
 	p.watcher, err = NewFileWatcher(p.parent.Settings.Legacy)
 	if err != nil {
 		log.Fatal(err)
 	}
+	// live reload proxy
+	if err := p.Proxy.Start(); err != nil {
+		p.Err(err)
+	}
 	defer func() {
-		close(p.stop)
 		p.watcher.Close()
+		p.Proxy.Close()
 	}()
+	// wait the projects it depends on
+	if !p.depends() {
+		close(p.stop)
+		wg.Done()
+		return
+	}
 	// before start checks
 	p.Before()
+	// scripts running alongside the project
+	background := p.background()
 	// start watcher
-	go p.Reload("", p.stop)
+	go p.reload(nil, p.stop, p.global)
+	// wait for a quiet period after the last event
+	queue := func(event fsnotify.Event) {
+		p.writes.record(event.Name)
+		pending = append(pending, event)
+		p.last.file = event.Name
+		p.last.time = time.Now()
+		flush = time.After(p.Watcher.debounce())
+	}
 L:
 	for {
 		select {
@@ -298,46 +489,97 @@ L:
 			if p.parent.Settings.Recovery.Events {
 				log.Println("File:", event.Name, "LastFile:", p.last.file, "Time:", time.Now(), "LastTime:", p.last.time)
 			}
-			if time.Now().Truncate(time.Second).After(p.last.time) {
-				// switch event type
-				switch event.Op {
-				case fsnotify.Chmod:
-				case fsnotify.Remove:
-					p.watcher.Remove(event.Name)
-					if p.Validate(event.Name, false) && ext(event.Name) != "" {
-						// stop and restart
-						close(p.stop)
-						p.stop = make(chan bool)
-						p.Change(event)
-						go p.Reload("", p.stop)
+			if p.isEnvFile(event.Name) {
+				// watched again if the file was replaced
+				p.watcher.Add(event.Name)
+				env = true
+				flush = time.After(p.Watcher.debounce())
+				continue
+			}
+			if p.ignore != nil && isIgnoreFile(event.Name) {
+				p.ignore.load(filepath.Dir(event.Name))
+			}
+			// switch event type
+			switch event.Op {
+			case fsnotify.Chmod:
+			case fsnotify.Remove:
+				p.watcher.Remove(event.Name)
+				if p.Validate(event.Name, false) && ext(event.Name) != "" {
+					queue(event)
+				}
+			default:
+				if p.Validate(event.Name, true) {
+					fi, err := os.Stat(event.Name)
+					if err != nil {
+						continue
 					}
-				default:
-					if p.Validate(event.Name, true) {
-						fi, err := os.Stat(event.Name)
-						if err != nil {
-							continue
-						}
-						if fi.IsDir() {
-							filepath.Walk(event.Name, p.walk)
-						} else {
-							// stop and restart
-							close(p.stop)
-							p.stop = make(chan bool)
-							p.Change(event)
-							go p.Reload(event.Name, p.stop)
-							p.last.time = time.Now().Truncate(time.Second)
-							p.last.file = event.Name
-						}
+					if fi.IsDir() {
+						filepath.Walk(event.Name, p.walk)
+					} else {
+						queue(event)
 					}
 				}
 			}
+		case <-flush:
+			if p.writes.busy() {
+				// the output of a triggered script reloads once it ends
+				flush = time.After(p.Watcher.debounce())
+				continue
+			}
+			if !paused {
+				// stop the running reload before the environment changes
+				close(p.stop)
+				p.stop = make(chan bool)
+			}
+			if env {
+				env = false
+				if err := p.environment(); err != nil {
+					p.Err(err)
+				}
+			}
+			if paused {
+				pending, flush = nil, nil
+				continue
+			}
+			for _, event := range pending {
+				p.Change(event)
+				p.publish(EventFileChanged, map[string]string{"path": event.Name, "op": event.Op.String()})
+			}
+			go p.Reload(merge(pending), p.stop)
+			pending, flush = nil, nil
+		case command := <-p.control:
+			close(p.stop)
+			p.stop = make(chan bool)
+			paused = command == ControlStop
+			if paused {
+				p.Proxy.release()
+				p.state.set(StateStopped)
+				msg = fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Stopped"))
+				out = BufferOut{Time: time.Now(), Text: "Stopped"}
+				p.stamp("log", out, msg, "")
+			} else {
+				go p.Reload(nil, p.stop)
+			}
+		case <-p.cascade:
+			// an upstream project rebuilt
+			if paused {
+				continue
+			}
+			close(p.stop)
+			p.stop = make(chan bool)
+			go p.Reload(nil, p.stop)
 		case err := <-p.watcher.Errors():
 			p.Err(err)
 		case <-p.exit:
+			background()
 			p.After()
 			break L
 		}
 	}
+	// the run process gets the interrupt and realize waits its exit
+	close(p.interrupt)
+	close(p.stop)
+	p.acquire(nil)
 	wg.Done()
 }
 
@@ -352,23 +594,19 @@ func (p *Project) Validate(path string, fcheck bool) bool {
 	}
 	// check for a valid ext or path
 	if e := ext(path); e != "" {
-		if len(p.Watcher.Exts) == 0 {
-			return false
-		}
 		// check ignored
 		for _, v := range p.Watcher.Ignore {
 			if v == e {
 				return false
 			}
 		}
-		// supported extensions
-		for index, v := range p.Watcher.Exts {
-			if e == v {
-				break
-			}
-			if index == len(p.Watcher.Exts)-1 {
+		// path patterns take precedence over the extensions list
+		if matched, include := p.Watcher.include(p.rel(path)); matched {
+			if !include {
 				return false
 			}
+		} else if !p.Watcher.supported(e) {
+			return false
 		}
 	}
 	if p.shouldIgnore(path) {
@@ -407,22 +645,34 @@ func (p *Project) pname(name string, color int) string {
 	return name
 }
 
-//  Tool logs the result of a go command
-func (p *Project) tools(stop <-chan bool, path string, fi os.FileInfo) {
+// Tools runs the go tools, per file on each file and per package once for each package
+func (p *Project) tools(stop <-chan bool, files []string, packages []string, affected []string) {
 	done := make(chan bool)
 	result := make(chan Response)
 	v := reflect.ValueOf(p.Tools)
+	x, list := p.expanded(), p.Tools.list()
 	go func() {
 		for i := 0; i < v.NumField()-1; i++ {
 			tool := v.Field(i).Interface().(Tool)
 			tool.parent = p
+			tool.Args = x.tools[list[i]]
 			if tool.Status && tool.isTool {
-				if fi.IsDir() {
-					if tool.dir {
-						result <- tool.Exec(path, stop)
+				paths := files
+				if tool.dir {
+					paths = packages
+					if tool.Scope == ScopeAffected && affected != nil {
+						paths = affected
+					}
+				}
+				env := p.buildEnvs(tool.Env)
+				for _, path := range paths {
+					r := tool.Exec(path, env, stop)
+					r.Path = path
+					select {
+					case result <- r:
+					case <-stop:
+						return
 					}
-				} else if !tool.dir {
-					result <- tool.Exec(path, stop)
 				}
 			}
 		}
@@ -435,50 +685,130 @@ func (p *Project) tools(stop <-chan bool, path string, fi os.FileInfo) {
 		case <-stop:
 			return
 		case r := <-result:
+			if len(r.Tests) > 0 {
+				p.results(r.Tests)
+			}
 			if r.Err != nil {
-				if fi.IsDir() {
-					path, _ = filepath.Abs(fi.Name())
+				stream := r.Err.Error()
+				if len(r.Diagnostics) > 0 {
+					stream = groupDiagnostics(r.Diagnostics)
 				}
-				msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), Red.Regular("there are some errors in"), ":", Magenta.Bold(path))
-				buff := BufferOut{Time: time.Now(), Text: "there are some errors in", Path: path, Type: r.Name, Stream: r.Err.Error()}
-				p.stamp("error", buff, msg, r.Err.Error())
+				msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), Red.Regular("there are some errors in"), ":", Magenta.Bold(r.Path))
+				buff := BufferOut{Time: time.Now(), Text: "there are some errors in", Path: r.Path, Type: r.Name, Stream: r.Err.Error(), Errors: diagnosticErrors(r.Diagnostics), Diagnostics: r.Diagnostics}
+				p.stamp("error", buff, msg, stream)
 			} else if r.Out != "" {
-				msg = fmt.Sprintln(p.pname(p.Name, 3), ":", Red.Bold(r.Name), Red.Regular("outputs"), ":", Blue.Bold(path))
-				buff := BufferOut{Time: time.Now(), Text: "outputs", Path: path, Type: r.Name, Stream: r.Out}
+				msg = fmt.Sprintln(p.pname(p.Name, 3), ":", Red.Bold(r.Name), Red.Regular("outputs"), ":", Blue.Bold(r.Path))
+				buff := BufferOut{Time: time.Now(), Text: "outputs", Path: r.Path, Type: r.Name, Stream: r.Out}
 				p.stamp("out", buff, msg, r.Out)
 			}
 		}
 	}
 }
 
-// Cmd after/before
-func (p *Project) cmd(stop <-chan bool, flag string, global bool) {
-	done := make(chan bool)
+// Results stores the test results and logs a summary for each package
+func (p *Project) results(tests []TestResult) {
+	p.Buffer.StdTest.Push(tests...)
+	for _, t := range tests {
+		p.publish(EventTestResult, t)
+	}
+	for _, t := range tests {
+		if t.Test != "" {
+			continue
+		}
+		pass, fail, skip := testSummary(tests, t.Package)
+		elapsed := big.NewFloat(t.Elapsed.Seconds()).Text('f', 3)
+		summary := fmt.Sprintf("%d passed, %d failed, %d skipped", pass, fail, skip)
+		out = BufferOut{Time: time.Now(), Text: t.Action + " " + t.Package + " in " + elapsed + " s (" + summary + ")", Path: t.Package, Type: "Test"}
+		if t.Action == TestFail {
+			msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold("Test"), Red.Regular(t.Package), "failed in", Magenta.Regular(elapsed, " s"), "("+summary+")")
+			p.stamp("error", out, msg, "")
+		} else {
+			msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Test"), t.Package, t.Action, "in", Magenta.Regular(elapsed, " s"), "("+summary+")")
+			p.stamp("log", out, msg, "")
+		}
+	}
+}
+
+// Healthcheck waits the readiness of the run process and logs the result
+func (p *Project) healthcheck(ready *readiness, stop <-chan bool) {
+	h := p.Tools.Run.Healthcheck
+	if h == nil {
+		ready.end(nil)
+		return
+	}
+	start := time.Now()
+	err := h.wait(p.dir(), p.buildEnvs(p.Tools.Run.Env), stop, ready.exited)
+	ready.end(err)
+	switch {
+	case err == errHealthStopped:
+	case err != nil:
+		msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold("Go Run"), Red.Regular(err.Error()))
+		out = BufferOut{Time: time.Now(), Text: err.Error(), Type: "Go Run"}
+		p.stamp("error", out, msg, "")
+	default:
+		elapsed := big.NewFloat(time.Since(start).Seconds()).Text('f', 3)
+		msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Go Run"), "ready in", Magenta.Regular(elapsed, " s"))
+		out = BufferOut{Time: time.Now(), Text: "ready in " + elapsed + " s", Type: "Go Run"}
+		p.stamp("log", out, msg, "")
+	}
+}
+
+// Cmd after/before, commands flagged as ready wait the run process readiness and the ones with on patterns
+// run only if a changed path matches, returns the failure policy of the failed commands, abort stops the sequence
+func (p *Project) cmd(stop <-chan bool, flag string, global bool, ready *readiness, paths []string) string {
+	done := make(chan string, 1)
 	result := make(chan Response)
+	var rels []string
+	for _, path := range paths {
+		rels = append(rels, p.rel(path))
+	}
+	x := p.expanded()
 	// commands sequence
 	go func() {
+		var failure string
+		defer func() { done <- failure }()
 		for _, cmd := range p.Watcher.Scripts {
-			if strings.ToLower(cmd.Type) == flag && cmd.Global == global {
-				result <- cmd.exec(p.Path, stop)
+			if strings.ToLower(cmd.Type) == flag && cmd.Global == global && !cmd.Background {
+				cmd.expanded = x.command(cmd.Cmd)
+				if len(cmd.On) > 0 && !cmd.triggered(rels) {
+					continue
+				}
+				if cmd.Ready && ready != nil && !ready.wait(stop) {
+					continue
+				}
+				if len(cmd.On) > 0 {
+					p.writes.begin()
+				}
+				r := cmd.run(x.path, p.buildEnvs(cmd.Env), stop, p.output(cmd))
+				if len(cmd.On) > 0 {
+					p.writes.end(p.Watcher.debounce())
+				}
+				select {
+				case result <- r:
+				case <-stop:
+					return
+				}
+				if r.Err == nil {
+					continue
+				}
+				switch cmd.OnFailure {
+				case FailureAbort:
+					failure = FailureAbort
+					return
+				case FailureSkipRun:
+					failure = FailureSkipRun
+				}
 			}
 		}
-		close(done)
 	}()
 	for {
 		select {
 		case <-stop:
-			return
-		case <-done:
-			return
+			return ""
+		case failure := <-done:
+			return failure
 		case r := <-result:
-			msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Command"), Green.Bold("\"")+r.Name+Green.Bold("\""))
-			if r.Err != nil {
-				out = BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: flag}
-				p.stamp("error", out, msg, fmt.Sprint(Red.Regular(r.Err.Error())))
-			} else {
-				out = BufferOut{Time: time.Now(), Text: r.Out, Type: flag}
-				p.stamp("log", out, msg, fmt.Sprint(r.Out))
-			}
+			p.script(flag, r)
 		}
 	}
 }
@@ -486,7 +816,13 @@ func (p *Project) cmd(stop <-chan bool, flag string, global bool) {
 // Watch the files tree of a project
 func (p *Project) walk(path string, info os.FileInfo, err error) error {
 	if p.shouldIgnore(path) {
-		return filepath.SkipDir
+		if info != nil && info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	if p.ignore != nil && info != nil && info.IsDir() {
+		p.ignore.load(path)
 	}
 
 	if p.Validate(path, true) {
@@ -495,7 +831,11 @@ func (p *Project) walk(path string, info os.FileInfo, err error) error {
 			if p.parent.Settings.Recovery.Index {
 				log.Println("Indexing", path)
 			}
-			p.tools(p.stop, path, info)
+			if info.IsDir() {
+				p.tools(p.stop, nil, []string{path}, nil)
+			} else {
+				p.tools(p.stop, []string{path}, nil, nil)
+			}
 			if info.IsDir() {
 				// tools dir
 				p.folders++
@@ -512,74 +852,242 @@ func (p *Project) shouldIgnore(path string) bool {
 	separator := string(os.PathSeparator)
 	// supported paths
 	for _, v := range p.Watcher.Ignore {
-		s := append([]string{p.Path}, strings.Split(v, separator)...)
+		if isGlob(v) {
+			// a pattern matching a folder ignores its content as well
+			rel := p.rel(path)
+			v = strings.TrimPrefix(v, "/")
+			if glob(v, rel) || glob(v+"/**", rel) {
+				return true
+			}
+			continue
+		}
+		s := append([]string{p.dir()}, strings.Split(v, separator)...)
 		abs, _ := filepath.Abs(filepath.Join(s...))
 		if path == abs || strings.HasPrefix(path, abs+separator) {
 			return true
 		}
 	}
+	// gitignore rules
+	if p.ignore != nil {
+		// git never matches its own folder
+		for _, name := range strings.Split(p.rel(path), "/") {
+			if name == ".git" {
+				return true
+			}
+		}
+		fi, err := os.Lstat(path)
+		return p.ignore.match(path, err == nil && fi.IsDir())
+	}
 	return false
 }
 
-// Print on files, cli, ws
-func (p *Project) stamp(t string, o BufferOut, msg string, stream string) {
-	ctime := time.Now()
-	content := []string{ctime.Format("2006-01-02 15:04:05"), strings.ToUpper(p.Name), ":", o.Text, "\r\n", stream}
-	switch t {
-	case "out":
-		p.Buffer.StdOut = append(p.Buffer.StdOut, o)
-		if p.parent.Settings.Files.Outputs.Status {
-			f := p.parent.Settings.Create(p.Path, p.parent.Settings.Files.Outputs.Name)
-			if _, err := f.WriteString(strings.Join(content, " ")); err != nil {
-				p.parent.Settings.Fatal(err, "")
-			}
+// Affected returns the changed packages and their dependents, the import graph is reloaded when outdated
+func (p *Project) affected(paths []string, packages []string) []string {
+	if p.graph == nil {
+		base, _ := filepath.Abs(p.dir())
+		p.graph = &graph{dir: base, cmd: p.Tools.golist(), stale: true}
+	}
+	p.graph.env = p.buildEnvs(nil)
+	if p.graph.outdated(paths) {
+		if err := p.graph.load(); err != nil {
+			p.Err(err)
+			return packages
 		}
-	case "log":
-		p.Buffer.StdLog = append(p.Buffer.StdLog, o)
-		if p.parent.Settings.Files.Logs.Status {
-			f := p.parent.Settings.Create(p.Path, p.parent.Settings.Files.Logs.Name)
-			if _, err := f.WriteString(strings.Join(content, " ")); err != nil {
-				p.parent.Settings.Fatal(err, "")
+	}
+	return p.graph.affected(packages)
+}
+
+// Rel returns the slash separated path relative to the project
+func (p *Project) rel(path string) string {
+	base, _ := filepath.Abs(p.dir())
+	path, _ = filepath.Abs(path)
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// roots returns the folders to walk, patterns are walked from their static prefix
+func (w *Watch) roots() (roots []string) {
+	seen := make(map[string]bool)
+	for _, v := range w.Paths {
+		if strings.HasPrefix(v, "!") {
+			continue
+		}
+		if isGlob(v) {
+			var static []string
+			for _, segment := range strings.Split(v, "/") {
+				if isGlob(segment) {
+					break
+				}
+				static = append(static, segment)
 			}
+			v = strings.Join(static, "/")
 		}
-	case "error":
-		p.Buffer.StdErr = append(p.Buffer.StdErr, o)
-		if p.parent.Settings.Files.Errors.Status {
-			f := p.parent.Settings.Create(p.Path, p.parent.Settings.Files.Errors.Name)
-			if _, err := f.WriteString(strings.Join(content, " ")); err != nil {
-				p.parent.Settings.Fatal(err, "")
+		if !seen[v] {
+			seen[v] = true
+			roots = append(roots, v)
+		}
+	}
+	return
+}
+
+// include check a file against the path patterns, the last matching pattern wins
+func (w *Watch) include(rel string) (matched bool, include bool) {
+	for _, v := range w.Paths {
+		negate := strings.HasPrefix(v, "!")
+		pattern := strings.TrimPrefix(strings.TrimPrefix(v, "!"), "/")
+		if !negate && !isGlob(pattern) {
+			continue
+		}
+		if glob(pattern, rel) || negate && glob(pattern+"/**", rel) {
+			matched, include = true, !negate
+		}
+	}
+	return
+}
+
+// supported check if an extension is in the extensions list
+func (w *Watch) supported(e string) bool {
+	for _, v := range w.Exts {
+		if v == e {
+			return true
+		}
+		if isGlob(v) {
+			if ok, _ := filepath.Match(v, e); ok {
+				return true
 			}
 		}
 	}
+	return false
+}
+
+// debounce returns the quiet period to wait before a reload
+func (w *Watch) debounce() time.Duration {
+	if w.Debounce > 0 {
+		return w.Debounce
+	}
+	return Debounce
+}
+
+// merge the events of the same path, in order of arrival
+func merge(events []fsnotify.Event) []fsnotify.Event {
+	var merged []fsnotify.Event
+	index := make(map[string]int)
+	for _, event := range events {
+		if i, ok := index[event.Name]; ok {
+			merged[i].Op |= event.Op
+			continue
+		}
+		index[event.Name] = len(merged)
+		merged = append(merged, event)
+	}
+	return merged
+}
+
+// names returns the paths of a list of events
+func names(events []fsnotify.Event) []string {
+	var paths []string
+	for _, event := range events {
+		paths = append(paths, event.Name)
+	}
+	return paths
+}
+
+// targets splits the changed paths into existing files and affected packages
+func targets(paths []string) (files []string, packages []string) {
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() && !seen[dir] {
+			seen[dir] = true
+			packages = append(packages, dir)
+		}
+	}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		switch {
+		case err != nil:
+			// a removed file still changes its package
+			add(filepath.Dir(path))
+		case fi.IsDir():
+			add(path)
+		default:
+			files = append(files, path)
+			add(filepath.Dir(path))
+		}
+	}
+	return
+}
+
+// Print on cli and publish the output to the subscribers, as ws and files
+func (p *Project) stamp(t string, o BufferOut, msg string, stream string) {
+	switch t {
+	case "out":
+		p.Buffer.StdOut.Push(o)
+	case "log":
+		p.Buffer.StdLog.Push(o)
+	case "error":
+		p.Buffer.StdErr.Push(o)
+	}
 	if msg != "" {
 		log.Print(msg)
 	}
 	if stream != "" {
 		fmt.Fprintln(Output, stream)
 	}
-	go func() {
-		p.parent.Sync <- "sync"
-	}()
+	p.publish(EventOutput, Line{Stream: t, Path: p.Path, Text: stream, Out: o})
 }
 
-func (p Project) buildEnvs() (envs []string) {
-	for k, v := range p.Env {
+// BuildEnvs returns the env of a subprocess, the os environment overridden by the env files,
+// then by the project env and then by the env of the tool or the command
+func (p *Project) buildEnvs(override map[string]string) (envs []string) {
+	vars := p.expanded().env
+	if vars == nil {
+		vars = p.Env
+	}
+	envs = os.Environ()
+	for k, v := range vars {
 		envs = append(envs, fmt.Sprintf("%s=%s", strings.Replace(k, "=", "", -1), v))
 	}
+	// the env of a tool or a command can reference the project env
+	lookup := func(key string) (string, bool) {
+		if v, ok := vars[key]; ok {
+			return v, true
+		}
+		return os.LookupEnv(key)
+	}
+	for k, v := range override {
+		envs = append(envs, fmt.Sprintf("%s=%s", strings.Replace(k, "=", "", -1), expand(v, lookup)))
+	}
 	return
 }
 
 // Run a project
-func (p *Project) run(path string, stream chan Response, stop <-chan bool) (err error) {
+func (p *Project) run(x *expansion, stream chan Response, stop <-chan bool) (err error) {
+	path := x.path
 	var args []string
 	var build *exec.Cmd
 	var r Response
+	var state *os.ProcessState
+	exited := make(chan struct{})
+	sig, err := p.Tools.Run.stopSignal()
+	if err != nil {
+		return err
+	}
 	defer func() {
 		// https://github.com/golang/go/issues/5615
 		// https://github.com/golang/go/issues/6720
-		if build != nil {
-			build.Process.Signal(os.Interrupt)
-			build.Process.Wait()
+		if build != nil && build.Process != nil {
+			// on exit the process is interrupted as from the terminal
+			select {
+			case <-p.interrupt:
+				sig = os.Interrupt
+			default:
+			}
+			if e := terminate(build, exited, sig, p.Tools.Run.stopTimeout()); e != nil && err == nil {
+				err = e
+			}
 		}
 	}()
 
@@ -590,13 +1098,17 @@ func (p *Project) run(path string, stream chan Response, stop <-chan bool) (err
 	errRegexp, err := regexp.Compile(p.ErrPattern)
 	if err != nil {
 		r.Err = err
-		stream <- r
+		select {
+		case stream <- r:
+		case <-stop:
+			return
+		}
 	} else {
 		isErrorText = errRegexp.MatchString
 	}
 
 	// add additional arguments
-	for _, arg := range p.Args {
+	for _, arg := range x.args {
 		a := strings.FieldsFunc(arg, func(i rune) bool {
 			return i == '"' || i == '=' || i == '\''
 		})
@@ -629,10 +1141,7 @@ func (p *Project) run(path string, stream chan Response, stop <-chan bool) (err
 			return errors.New("project not found")
 		}
 	}
-	appendEnvs := p.buildEnvs()
-	if len(appendEnvs) > 0 {
-		build.Env = append(build.Env, appendEnvs...)
-	}
+	build.Env = p.buildEnvs(p.Tools.Run.Env)
 	// scan project stream
 	stdout, err := build.StdoutPipe()
 	stderr, err := build.StderrPipe()
@@ -642,46 +1151,67 @@ func (p *Project) run(path string, stream chan Response, stop <-chan bool) (err
 	if p.Tools.Run.Dir != "" {
 		build.Dir = p.Tools.Run.Dir
 	}
+	// stop the children of the process as well
+	setGroup(build)
 	if err := build.Start(); err != nil {
 		return err
 	}
+	go func() {
+		state, _ = build.Process.Wait()
+		close(exited)
+	}()
 	execOutput, execError := bufio.NewScanner(stdout), bufio.NewScanner(stderr)
 	stopOutput, stopError := make(chan bool, 1), make(chan bool, 1)
+	// last lines of stderr, used as crash reason
+	var tail []string
 	scanner := func(stop chan bool, output *bufio.Scanner, isError bool) {
 		for output.Scan() {
+			var r Response
 			text := output.Text()
+			if isError {
+				tail = append(tail, text)
+				if len(tail) > crashLines {
+					tail = tail[1:]
+				}
+			}
 			if isError && !isErrorText(text) {
 				r.Err = errors.New(text)
-				stream <- r
-				r.Err = nil
 			} else {
 				r.Out = text
-				stream <- r
-				r.Out = ""
 			}
+			stream <- r
 		}
 		close(stop)
 	}
 	go scanner(stopOutput, execOutput, false)
 	go scanner(stopError, execError, true)
-	for {
+	select {
+	case <-stop:
+		return
+	case <-exited:
+	}
+	// wait the remaining output before reporting the exit
+	for _, scan := range []chan bool{stopOutput, stopError} {
 		select {
 		case <-stop:
 			return
-		case <-stopOutput:
-			return
-		case <-stopError:
-			return
+		case <-scan:
 		}
 	}
+	return &exitError{state: state, reason: strings.Join(tail, "\n")}
 }
 
 // Print with time after
 func (r *Response) print(start time.Time, p *Project) {
 	if r.Err != nil {
-		msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), "\n", r.Err.Error())
-		out = BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: r.Name, Stream: r.Out}
+		text := r.Err.Error()
+		if len(r.Diagnostics) > 0 {
+			text = groupDiagnostics(r.Diagnostics)
+		}
+		msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), "\n", text)
+		out = BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: r.Name, Stream: r.Out, Errors: diagnosticErrors(r.Diagnostics), Diagnostics: r.Diagnostics}
 		p.stamp("error", out, msg, r.Out)
+		p.publish(EventBuildFailed, out)
 	} else {
 		msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold(r.Name), "completed in", Magenta.Regular(big.NewFloat(float64(time.Since(start).Seconds())).Text('f', 3), " s"))
 		out = BufferOut{Time: time.Now(), Text: r.Name + " in " + big.NewFloat(float64(time.Since(start).Seconds())).Text('f', 3) + " s"}
@@ -689,13 +1219,35 @@ func (r *Response) print(start time.Time, p *Project) {
 	}
 }
 
-// Exec an additional command from a defined path if specified
-func (c *Command) exec(base string, stop <-chan bool) (response Response) {
+// Run executes the command, again up to its retries if it fails
+func (c *Command) run(base string, env []string, stop <-chan bool, lines func(stream, text string)) (response Response) {
+	for attempt := 0; ; attempt++ {
+		response = c.exec(base, env, stop, lines)
+		if response.Err == nil || attempt >= c.Retries {
+			return
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
+
+// Exec an additional command from a defined path if specified, with the env if not nil,
+// lines receives the output as it's written
+func (c *Command) exec(base string, env []string, stop <-chan bool, lines func(stream, text string)) (response Response) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	done := make(chan error)
-	args := strings.Split(strings.Replace(strings.Replace(c.Cmd, "'", "", -1), "\"", "", -1), " ")
+	done := make(chan error, 1)
+	response.Name = c.Cmd
+	args, err := c.args()
+	if err != nil {
+		response.Err, response.Code = err, -1
+		return
+	}
 	ex := exec.Command(args[0], args[1:]...)
+	ex.Env = env
 	ex.Dir = base
 	// make cmd path
 	if c.Path != "" {
@@ -707,21 +1259,65 @@ func (c *Command) exec(base string, stop <-chan bool) (response Response) {
 	}
 	ex.Stdout = &stdout
 	ex.Stderr = &stderr
+	if lines != nil {
+		outLines := &lineWriter{fn: func(text string) { lines("stdout", text) }}
+		errLines := &lineWriter{fn: func(text string) { lines("stderr", text) }}
+		ex.Stdout = io.MultiWriter(&stdout, outLines)
+		ex.Stderr = io.MultiWriter(&stderr, errLines)
+		defer outLines.flush()
+		defer errLines.flush()
+	}
+	// a timeout kills the children as well
+	setGroup(ex)
 	// Start command
-	ex.Start()
+	if err := ex.Start(); err != nil {
+		response.Err, response.Code = err, -1
+		return
+	}
 	go func() { done <- ex.Wait() }()
+	var timeout <-chan time.Time
+	if c.Timeout > 0 {
+		timeout = time.After(c.Timeout)
+	}
 	// Wait a result
 	select {
 	case <-stop:
 		// Stop running command
-		ex.Process.Kill()
+		killGroup(ex)
+	case <-timeout:
+		killGroup(ex)
+		<-done
+		response.Out = stdout.String()
+		response.Err, response.Code = errors.New("timeout after "+c.Timeout.String()+"\n"+stderr.String()+stdout.String()), -1
 	case err := <-done:
 		// Command completed
-		response.Name = c.Cmd
 		response.Out = stdout.String()
 		if err != nil {
+			response.Code = -1
+			if exit, ok := err.(*exec.ExitError); ok {
+				response.Code = exit.ExitCode()
+			}
 			response.Err = errors.New(stderr.String() + stdout.String())
 		}
 	}
 	return
 }
+
+// Args returns the arguments of the expanded command, split as shell words or passed to a shell
+func (c *Command) args() ([]string, error) {
+	command := c.Cmd
+	if c.expanded != "" {
+		command = c.expanded
+	}
+	if c.shell() {
+		return shellArgs(c.Shell, command), nil
+	}
+	args, err := shellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
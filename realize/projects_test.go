@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"github.com/fsnotify/fsnotify"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -92,15 +94,66 @@ func TestProject_Reload(t *testing.T) {
 	r.Settings.Legacy.Interval = 0
 	r.Projects[0].watcher, _ = NewFileWatcher(r.Settings.Legacy)
 	r.Reload = func(context Context) {
-		log.Println(context.Path)
+		log.Println(context.Path, len(context.Paths), context.Events[0].Op)
 	}
 	stop := make(chan bool)
-	r.Projects[0].Reload(input, stop)
-	if !strings.Contains(buf.String(), input) {
+	r.Projects[0].Reload([]fsnotify.Event{{Name: input, Op: fsnotify.Write}}, stop)
+	if !strings.Contains(buf.String(), input+" 1 WRITE") {
 		t.Error("Unexpected error")
 	}
 }
 
+func TestProject_Debounce(t *testing.T) {
+	w := Watch{}
+	if w.debounce() != Debounce {
+		t.Error("Expected default debounce instead", w.debounce())
+	}
+	w.Debounce = time.Second
+	if w.debounce() != time.Second {
+		t.Error("Expected custom debounce instead", w.debounce())
+	}
+	events := []fsnotify.Event{
+		{Name: "a.go", Op: fsnotify.Create},
+		{Name: "b.go", Op: fsnotify.Create},
+		{Name: "a.go", Op: fsnotify.Write},
+	}
+	merged := merge(events)
+	if len(merged) != 2 || merged[0].Name != "a.go" || merged[1].Name != "b.go" {
+		t.Fatal("Unexpected events", merged)
+	}
+	if merged[0].Op != fsnotify.Create|fsnotify.Write {
+		t.Error("Unexpected op", merged[0].Op)
+	}
+	paths := names(merged)
+	if len(paths) != 2 || paths[0] != "a.go" || paths[1] != "b.go" {
+		t.Error("Unexpected paths", paths)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "targets_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pkg := filepath.Join(dir, "pkg")
+	os.Mkdir(pkg, Permission)
+	for _, name := range []string{"a.go", "b.go"} {
+		ioutil.WriteFile(filepath.Join(pkg, name), []byte("package pkg"), Permission)
+	}
+	files, packages := targets([]string{
+		filepath.Join(pkg, "a.go"),
+		filepath.Join(pkg, "b.go"),
+		filepath.Join(dir, "removed.go"),
+	})
+	if len(files) != 2 {
+		t.Error("Expected two files instead", files)
+	}
+	if len(packages) != 2 || packages[0] != pkg || packages[1] != dir {
+		t.Error("Unexpected packages", packages)
+	}
+}
+
 func TestProject_Validate(t *testing.T) {
 	data := map[string]bool{
 		"":                        false,
@@ -144,3 +197,49 @@ func TestProject_Watch(t *testing.T) {
 	r.Projects[0].Watch(&wg)
 	wg.Wait()
 }
+
+func TestProject_ValidateGlob(t *testing.T) {
+	data := map[string]bool{
+		"/project/main.go":                       true,
+		"/project/main.html":                     false,
+		"/project/internal/a/b.go":               true,
+		"/project/internal/a/b_mock.go":          false,
+		"/project/web/templates/a/index.tmpl":    true,
+		"/project/web/static/index.tmpl":         false,
+		"/project/pkg/testdata/a.go":             false,
+		"/project/pkg/x/testdata/nested/a.go":    false,
+		"/project/pkg/x/a.go":                    true,
+		"/project/internal/a/b_generated.pb.go":  true,
+		"/project/internal/a/b_generated.pb.txt": false,
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{
+		parent: &r,
+		Path:   "/project",
+		Watcher: Watch{
+			Paths:  []string{"/", "internal/**/*.go", "!**/*_mock.go", "web/templates/**/*.tmpl"},
+			Exts:   []string{"g?"},
+			Ignore: []string{"**/testdata"},
+		},
+	})
+	for i, v := range data {
+		result := r.Projects[0].Validate(i, false)
+		if result != v {
+			t.Error("Unexpected error", i, "expected", v, result)
+		}
+	}
+}
+
+func TestWatch_Roots(t *testing.T) {
+	w := Watch{Paths: []string{"/", "internal/**/*.go", "!**/*_mock.go", "web/templates/**/*.tmpl", "internal/*/x.go"}}
+	roots := w.roots()
+	expected := []string{"/", "internal", "web/templates"}
+	if len(roots) != len(expected) {
+		t.Fatal("Unexpected roots", roots)
+	}
+	for i := range expected {
+		if roots[i] != expected[i] {
+			t.Error("Unexpected root", roots[i], "instead", expected[i])
+		}
+	}
+}
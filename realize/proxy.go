@@ -0,0 +1,226 @@
+package realize
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyPath is the server sent events endpoint used by the injected script
+const ProxyPath = "/__realize/reload"
+
+// ProxyTimeout is the default max time a request is held while the project restarts
+const ProxyTimeout = 30 * time.Second
+
+// script injected into the html pages, it reloads the page after a project reload
+const proxyScript = `<script>(function(){var s=new EventSource("` + ProxyPath + `");s.onmessage=function(){s.close();location.reload()}})()</script>`
+
+// Proxy is a live reload reverse proxy in front of the run process
+type Proxy struct {
+	Status   bool          `yaml:"status" json:"status"`
+	Listen   string        `yaml:"listen" json:"listen"`
+	Upstream string        `yaml:"upstream" json:"upstream"`
+	Timeout  time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	mu       sync.Mutex
+	ready    chan struct{}
+	clients  map[chan struct{}]bool
+	server   *http.Server
+	proxy    *httputil.ReverseProxy
+	target   *url.URL
+}
+
+// Start the proxy server
+func (x *Proxy) Start() error {
+	if x == nil || !x.Status {
+		return nil
+	}
+	upstream := x.Upstream
+	if !strings.Contains(upstream, "://") {
+		upstream = "http://" + upstream
+	}
+	target, err := url.Parse(upstream)
+	if err != nil {
+		return err
+	}
+	x.mu.Lock()
+	x.target = target
+	x.ready = make(chan struct{})
+	x.clients = make(map[chan struct{}]bool)
+	x.proxy = httputil.NewSingleHostReverseProxy(target)
+	director := x.proxy.Director
+	x.proxy.Director = func(r *http.Request) {
+		director(r)
+		// plain responses are required to inject the script
+		r.Header.Del("Accept-Encoding")
+	}
+	x.proxy.ModifyResponse = inject
+	x.server = &http.Server{Addr: x.Listen, Handler: x}
+	x.mu.Unlock()
+	go func() {
+		log.Println(Yellow.Regular("[")+"PROXY"+Yellow.Regular("]"), ":", "Started on", x.Listen, "->", target.Host)
+		if err := x.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Println(Yellow.Regular("[")+"PROXY"+Yellow.Regular("]"), ":", Red.Regular(err.Error()))
+		}
+	}()
+	return nil
+}
+
+// Close the proxy server
+func (x *Proxy) Close() error {
+	if x == nil || x.server == nil {
+		return nil
+	}
+	return x.server.Close()
+}
+
+// ServeHTTP holds the requests while the project restarts and forwards them to the upstream
+func (x *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == ProxyPath {
+		x.events(w, r)
+		return
+	}
+	x.mu.Lock()
+	ready := x.ready
+	x.mu.Unlock()
+	select {
+	case <-ready:
+	case <-r.Context().Done():
+		return
+	case <-time.After(x.timeout()):
+	}
+	x.proxy.ServeHTTP(w, r)
+}
+
+// hold the incoming requests until the next release
+func (x *Proxy) hold() {
+	if x == nil || x.ready == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	select {
+	case <-x.ready:
+		x.ready = make(chan struct{})
+	default:
+	}
+}
+
+// release the held requests
+func (x *Proxy) release() {
+	if x == nil || x.ready == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	select {
+	case <-x.ready:
+	default:
+		close(x.ready)
+	}
+}
+
+// reload waits the upstream, releases the held requests and reloads the connected pages
+func (x *Proxy) reload(stop <-chan bool) {
+	if x == nil || x.ready == nil {
+		return
+	}
+	timeout := time.After(x.timeout())
+	for {
+		conn, err := net.DialTimeout("tcp", x.target.Host, time.Second)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		select {
+		case <-stop:
+			return
+		case <-timeout:
+			x.release()
+			return
+		case <-time.After(HealthInterval):
+		}
+	}
+	x.release()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for client := range x.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// events streams the reload notifications to a page
+func (x *Proxy) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	client := make(chan struct{}, 1)
+	x.mu.Lock()
+	x.clients[client] = true
+	x.mu.Unlock()
+	defer func() {
+		x.mu.Lock()
+		delete(x.clients, client)
+		x.mu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (x *Proxy) timeout() time.Duration {
+	if x.Timeout > 0 {
+		return x.Timeout
+	}
+	return ProxyTimeout
+}
+
+// inject the reload script into the html responses, the ones without a body are left as they are
+func inject(resp *http.Response) error {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if i := bytes.LastIndex(bytes.ToLower(body), []byte("</body>")); i >= 0 {
+		body = append(body[:i], append([]byte(proxyScript), body[i:]...)...)
+	} else {
+		body = append(body, proxyScript...)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
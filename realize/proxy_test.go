@@ -0,0 +1,100 @@
+package realize
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxy_Reload(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body><h1>app</h1></body></html>"))
+	}))
+	defer upstream.Close()
+	x := &Proxy{Status: true, Listen: "127.0.0.1:0", Upstream: upstream.URL, Timeout: 2 * time.Second}
+	if err := x.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	srv := httptest.NewServer(x)
+	defer srv.Close()
+
+	// requests are held until the first reload
+	result := make(chan string)
+	go func() {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		result <- string(body)
+	}()
+	select {
+	case <-result:
+		t.Fatal("Unexpected response before the reload")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	events, err := http.Get(srv.URL + ProxyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Body.Close()
+	// wait the registration of the events client
+	for i := 0; i < 50; i++ {
+		x.mu.Lock()
+		n := len(x.clients)
+		x.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	x.reload(nil)
+	body := <-result
+	if !strings.Contains(body, "<h1>app</h1>"+proxyScript+"</body>") {
+		t.Error("Expected injected script", body)
+	}
+	line, err := bufio.NewReader(events.Body).ReadString('\n')
+	if err != nil || line != "data: reload\n" {
+		t.Error("Expected reload event", line, err)
+	}
+}
+
+func TestProxy_Nil(t *testing.T) {
+	var x *Proxy
+	x.hold()
+	x.release()
+	x.reload(nil)
+	if err := x.Start(); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	if err := x.Close(); err != nil {
+		t.Error("Unexpected error", err)
+	}
+}
+
+func TestInject_NoBody(t *testing.T) {
+	html := http.Header{"Content-Type": {"text/html"}}
+	head, _ := http.NewRequest(http.MethodHead, "http://localhost", nil)
+	get, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	for _, resp := range []*http.Response{
+		{StatusCode: http.StatusOK, Header: html, Request: head, Body: ioutil.NopCloser(strings.NewReader("")), ContentLength: 120},
+		{StatusCode: http.StatusNotModified, Header: html, Request: get, Body: http.NoBody},
+		{StatusCode: http.StatusNoContent, Header: html, Request: get, Body: http.NoBody},
+	} {
+		if err := inject(resp); err != nil {
+			t.Fatal(err)
+		}
+		if body, _ := ioutil.ReadAll(resp.Body); len(body) > 0 || resp.Header.Get("Content-Length") != "" {
+			t.Error("Unexpected body injected", resp.StatusCode, string(body))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package realize
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lineWriter passes each complete line written to fn, the last partial one on flush
+type lineWriter struct {
+	mu      sync.Mutex
+	partial []byte
+	fn      func(string)
+}
+
+func (w *lineWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.partial = append(w.partial, b...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(strings.TrimSuffix(string(w.partial[:i]), "\r"))
+		w.partial = w.partial[i+1:]
+	}
+	w.partial = append([]byte(nil), w.partial...)
+	return len(b), nil
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.fn(string(w.partial))
+		w.partial = nil
+	}
+}
+
+// output streams the lines of a script, prefixed by its command
+func (p *Project) output(c Command) func(stream, text string) {
+	return func(stream, text string) {
+		msg := fmt.Sprintln(p.pname(p.Name, 3), ":", Magenta.Regular(c.Cmd), ":", text)
+		out := BufferOut{Time: time.Now(), Text: text, Type: c.Type}
+		if stream == "stderr" {
+			p.stamp("error", out, msg, "")
+		} else {
+			p.stamp("log", out, msg, "")
+		}
+	}
+}
+
+// script prints the result of a script, its output was already streamed
+func (p *Project) script(flag string, r Response) {
+	if r.Err != nil {
+		reason := "exit status " + strconv.Itoa(r.Code)
+		if r.Code < 0 {
+			reason = strings.SplitN(r.Err.Error(), "\n", 2)[0]
+		}
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold("Command"), Red.Bold("\"")+r.Name+Red.Bold("\""), Red.Regular(reason))
+		out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: flag}
+		p.stamp("error", out, msg, "")
+		return
+	}
+	msg := fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Command"), Green.Bold("\"")+r.Name+Green.Bold("\""), "completed")
+	out := BufferOut{Time: time.Now(), Text: r.Name + " completed", Type: flag}
+	p.stamp("log", out, msg, "")
+}
+
+// background starts the scripts running alongside the project, stop kills them and waits their end
+func (p *Project) background() (stop func()) {
+	done := make(chan bool)
+	var wg sync.WaitGroup
+	x := p.expanded()
+	for _, c := range p.Watcher.Scripts {
+		if !c.Background {
+			continue
+		}
+		c.expanded = x.command(c.Cmd)
+		wg.Add(1)
+		go func(c Command) {
+			defer wg.Done()
+			msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Background"), Blue.Bold("\"")+c.Cmd+Blue.Bold("\""))
+			out := BufferOut{Time: time.Now(), Text: "Background " + c.Cmd, Type: "background"}
+			p.stamp("log", out, msg, "")
+			r := c.exec(x.path, p.buildEnvs(c.Env), done, p.output(c))
+			select {
+			case <-done:
+				// killed with the project
+			default:
+				p.script("background", r)
+			}
+		}(c)
+	}
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,21 @@
+package realize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := &lineWriter{fn: func(text string) { lines = append(lines, text) }}
+	w.Write([]byte("one\ntw"))
+	w.Write([]byte("o\r\nthr"))
+	if !reflect.DeepEqual(lines, []string{"one", "two"}) {
+		t.Error("Unexpected lines", lines)
+	}
+	w.flush()
+	w.flush()
+	if !reflect.DeepEqual(lines, []string{"one", "two", "thr"}) {
+		t.Error("Unexpected lines after flush", lines)
+	}
+}
@@ -35,17 +35,14 @@ type Server struct {
 // Websocket projects
 func (s *Server) projects(c echo.Context) (err error) {
 	websocket.Handler(func(ws *websocket.Conn) {
-		msg, _ := json.Marshal(s.Parent)
-		err = websocket.Message.Send(ws, string(msg))
+		// each connection has its own subscription, only the last state matters
+		sub := s.Parent.Bus.Subscribe(SubscriberBuffer, DropOldest, nil)
+		defer sub.Close()
+		err = websocket.Message.Send(ws, string(s.marshal()))
 		go func() {
-			for {
-				select {
-				case <-s.Parent.Sync:
-					msg, _ := json.Marshal(s.Parent)
-					err = websocket.Message.Send(ws, string(msg))
-					if err != nil {
-						break
-					}
+			for range sub.Events() {
+				if err := websocket.Message.Send(ws, string(s.marshal())); err != nil {
+					return
 				}
 			}
 		}()
@@ -56,9 +53,22 @@ func (s *Server) projects(c echo.Context) (err error) {
 			if err != nil {
 				break
 			} else {
+				s.Parent.mu.Lock()
 				err := json.Unmarshal([]byte(text), &s.Parent)
 				if err == nil {
 					s.Parent.Settings.Write(s.Parent)
+				}
+				s.Parent.mu.Unlock()
+				if err == nil {
+					// the running projects expand the new config
+					for _, p := range s.Parent.projects() {
+						if p.parent == nil {
+							continue
+						}
+						if err := p.environment(); err != nil {
+							p.Err(err)
+						}
+					}
 					break
 				}
 			}
@@ -68,6 +78,19 @@ func (s *Server) projects(c echo.Context) (err error) {
 	return nil
 }
 
+// marshal returns the json of realize with the running projects
+func (s *Server) marshal() []byte {
+	r := s.Parent
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	msg, _ := json.Marshal(struct {
+		Settings *Settings  `json:"settings"`
+		Server   *Server    `json:"server,omitempty"`
+		Projects []*Project `json:"schema"`
+	}{&r.Settings, &r.Server, r.live()})
+	return msg
+}
+
 // Render return a web pages defined in bindata
 func (s *Server) render(c echo.Context, path string, mime int) error {
 	data, err := Asset(path)
@@ -111,6 +134,10 @@ func (s *Server) Start() (err error) {
 		e := echo.New()
 		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 			Level: 2,
+			Skipper: func(c echo.Context) bool {
+				// streams are flushed event by event
+				return c.Path() == "/events"
+			},
 		}))
 		e.Use(middleware.Recover())
 
@@ -152,6 +179,13 @@ func (s *Server) Start() (err error) {
 			return s.render(c, "assets/assets/img/svg/ic_settings_black_48px.svg", 4)
 		})
 
+		// rest api
+		s.api(e)
+		// server sent events
+		if s.Parent.Bus == nil {
+			s.Parent.Bus = NewBus()
+		}
+		e.GET("/events", s.events)
 		//websocket
 		e.GET("/ws", s.projects)
 		e.HideBanner = true
@@ -1,11 +1,13 @@
 package realize
 
 import (
+	"encoding/json"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -29,9 +31,10 @@ const (
 // Settings defines a group of general settings and options
 type Settings struct {
 	Files     `yaml:"files,omitempty" json:"files,omitempty"`
-	FileLimit int32    `yaml:"flimit,omitempty" json:"flimit,omitempty"`
-	Legacy    Legacy   `yaml:"legacy" json:"legacy"`
-	Recovery  Recovery `yaml:"recovery,omitempty" json:"recovery,omitempty"`
+	FileLimit int32          `yaml:"flimit,omitempty" json:"flimit,omitempty"`
+	Legacy    Legacy         `yaml:"legacy" json:"legacy"`
+	Recovery  Recovery       `yaml:"recovery,omitempty" json:"recovery,omitempty"`
+	Buffer    BufferSettings `yaml:"buffer,omitempty" json:"buffer,omitempty"`
 }
 
 type Recovery struct {
@@ -54,11 +57,15 @@ type Files struct {
 	Errors  Resource `yaml:"errors,omitempty" json:"error,omitempty"`
 }
 
-// Resource status and file name
+// Resource status, file name, format and rotation
 type Resource struct {
-	Status bool
-	Path   string
-	Name   string
+	Status  bool
+	Path    string
+	Name    string
+	Format  string        `yaml:"format,omitempty" json:"format,omitempty"`
+	MaxSize int64         `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+	MaxAge  time.Duration `yaml:"max_age,omitempty" json:"max_age,omitempty"`
+	Backups int           `yaml:"backups,omitempty" json:"backups,omitempty"`
 }
 
 // Set legacy watcher with an interval
@@ -129,3 +136,53 @@ func (s Settings) Create(path string, name string) *os.File {
 	s.Fatal(err)
 	return out
 }
+
+// Record appends the output events on the enabled log files until the channel is closed
+func (s *Settings) record(events <-chan Event) {
+	files := make(map[string]*logFile)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for e := range events {
+		o, ok := e.Data.(Line)
+		if !ok {
+			continue
+		}
+		var res Resource
+		switch o.Stream {
+		case "out":
+			res = s.Files.Outputs
+		case "log":
+			res = s.Files.Logs
+		case "error":
+			res = s.Files.Errors
+		}
+		if !res.Status {
+			continue
+		}
+		name := filepath.Join(o.Path, res.Name)
+		f, ok := files[name]
+		if !ok {
+			var err error
+			if f, err = openLog(name, res); err != nil {
+				s.Fatal(err, "")
+				continue
+			}
+			files[name] = f
+		}
+		var line []byte
+		switch res.Format {
+		case FormatJSON:
+			line, _ = json.Marshal(logEntry{Project: e.Project, Stream: o.Stream, Type: o.Out.Type, Time: e.Time, Path: o.Out.Path, Text: o.Out.Text})
+			line = append(line, '\n')
+		default:
+			content := []string{e.Time.Format("2006-01-02 15:04:05"), strings.ToUpper(e.Project), ":", o.Out.Text, "\r\n", o.Text}
+			line = []byte(strings.Join(content, " "))
+		}
+		if _, err := f.Write(line); err != nil {
+			s.Fatal(err, "")
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package realize
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -99,3 +102,54 @@ func TestSettings_Create(t *testing.T) {
 	f := s.Create(p, "io_test")
 	os.Remove(f.Name())
 }
+
+func TestSettings_Record(t *testing.T) {
+	dir, err := ioutil.TempDir("", "record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := Settings{}
+	s.Files.Logs = Resource{Status: true, Name: FileLog}
+	events := make(chan Event, 3)
+	events <- Event{Type: EventOutput, Project: "test", Data: Line{Stream: "log", Path: dir, Out: BufferOut{Text: "first"}}}
+	events <- Event{Type: EventOutput, Project: "test", Data: Line{Stream: "out", Path: dir, Out: BufferOut{Text: "skipped"}}}
+	events <- Event{Type: EventOutput, Project: "test", Data: Line{Stream: "log", Path: dir, Out: BufferOut{Text: "second"}}}
+	close(events)
+	s.record(events)
+	content, err := ioutil.ReadFile(filepath.Join(dir, FileLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "TEST : first") || !strings.Contains(string(content), "second") {
+		t.Error("Unexpected log file", string(content))
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileOut)); err == nil {
+		t.Error("Unexpected outputs file")
+	}
+}
+
+func TestSettings_RecordJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := Settings{}
+	s.Files.Errors = Resource{Status: true, Name: FileErr, Format: FormatJSON}
+	events := make(chan Event, 1)
+	events <- Event{Type: EventOutput, Project: "test", Data: Line{Stream: "error", Path: dir, Out: BufferOut{Text: "failed", Type: "Go Build", Path: "main.go"}}}
+	close(events)
+	s.record(events)
+	content, err := ioutil.ReadFile(filepath.Join(dir, FileErr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry logEntry
+	if err := json.Unmarshal(content, &entry); err != nil || !strings.HasSuffix(string(content), "}\n") {
+		t.Fatal("Unexpected json line", string(content), err)
+	}
+	if entry.Project != "test" || entry.Stream != "error" || entry.Type != "Go Build" || entry.Path != "main.go" || entry.Text != "failed" {
+		t.Error("Unexpected entry", entry)
+	}
+}
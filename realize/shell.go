@@ -0,0 +1,98 @@
+package realize
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// shellWords splits a command as the shell of the platform does, without expansions
+func shellWords(s string) ([]string, error) {
+	return splitWords(s, backslashEscape)
+}
+
+// splitWords splits a command as a posix shell does, with escape false
+// the backslashes are literal as in windows paths, except before a double quote
+func splitWords(s string, escape bool) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	// a word can be an empty quoted string
+	started := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if started {
+				words = append(words, word.String())
+				word.Reset()
+				started = false
+			}
+		case c == '\\' && escape:
+			started = true
+			if i+1 < len(s) {
+				i++
+				if s[i] != '\n' {
+					word.WriteByte(s[i])
+				}
+			}
+		case c == '\'':
+			started = true
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			started = true
+			closed := false
+			escaped := "\"\\$`\n"
+			if !escape {
+				escaped = "\""
+			}
+			for i++; i < len(s); i++ {
+				if s[i] == '"' {
+					closed = true
+					break
+				}
+				// only these characters are escaped in double quotes
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(escaped, s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+		default:
+			started = true
+			word.WriteByte(c)
+		}
+	}
+	if started {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// shellArgs returns the arguments to run a command through a shell, true is the default one
+func shellArgs(shell string, cmd string) []string {
+	if shell == "true" {
+		shell = defaultShell
+	}
+	switch strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe") {
+	case "cmd":
+		return []string{shell, "/C", cmd}
+	case "powershell", "pwsh":
+		return []string{shell, "-Command", cmd}
+	}
+	return []string{shell, "-c", cmd}
+}
+
+// shell returns if a command runs through a shell
+func (c *Command) shell() bool {
+	return c.Shell != "" && c.Shell != "false"
+}
@@ -0,0 +1,62 @@
+package realize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShellWords(t *testing.T) {
+	for in, out := range map[string][]string{
+		`go build -o bin/app`:             {"go", "build", "-o", "bin/app"},
+		`sh -c "make gen && go generate"`: {"sh", "-c", "make gen && go generate"},
+		`echo 'a  b' "c \"d\" \$e" f\ g`:  {"echo", "a  b", `c "d" $e`, "f g"},
+		`echo "" '' x`:                    {"echo", "", "", "x"},
+		"  tab\tsep  ":                    {"tab", "sep"},
+		`a"b c"d`:                         {"ab cd"},
+	} {
+		words, err := splitWords(in, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(words, "|") != strings.Join(out, "|") || len(words) != len(out) {
+			t.Error("Unexpected words of", in, words)
+		}
+	}
+	for _, in := range []string{`echo "open`, `echo 'open`} {
+		if _, err := shellWords(in); err == nil {
+			t.Error("Expected an unterminated quote error", in)
+		}
+	}
+	// windows paths
+	for in, out := range map[string][]string{
+		`C:\tools\gen.exe -o .\out`:                {`C:\tools\gen.exe`, "-o", `.\out`},
+		`"C:\Program Files\gen.exe" "\\srv\share"`: {`C:\Program Files\gen.exe`, `\\srv\share`},
+		`echo "say \"hi\""`:                        {"echo", `say "hi"`},
+	} {
+		words, err := splitWords(in, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(words, "|") != strings.Join(out, "|") || len(words) != len(out) {
+			t.Error("Unexpected words of", in, words)
+		}
+	}
+}
+
+func TestShellArgs(t *testing.T) {
+	if args := shellArgs("bash", "a | b"); strings.Join(args, "|") != "bash|-c|a | b" {
+		t.Error("Unexpected args", args)
+	}
+	if args := shellArgs("cmd.exe", "dir"); args[1] != "/C" {
+		t.Error("Unexpected args", args)
+	}
+	if args := shellArgs("true", "x"); args[0] != defaultShell {
+		t.Error("Expected the default shell", args)
+	}
+	if (&Command{Shell: "false"}).shell() || !(&Command{Shell: "true"}).shell() {
+		t.Error("Unexpected shell option")
+	}
+	if _, err := (&Command{Cmd: "  "}).args(); err == nil {
+		t.Error("Expected an empty command error")
+	}
+}
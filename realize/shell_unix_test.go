@@ -0,0 +1,209 @@
+// +build !windows
+
+package realize
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommand_Exec(t *testing.T) {
+	c := Command{Cmd: `sh -c "echo 'a b' && exit 3"`}
+	r := c.exec(".", nil, nil, nil)
+	if r.Err == nil || r.Code != 3 || r.Out != "a b\n" {
+		t.Error("Unexpected response", r.Out, r.Code, r.Err)
+	}
+	c = Command{Cmd: "echo one two | tr a-z A-Z > /dev/stdout", Shell: "true"}
+	if r := c.exec(".", nil, nil, nil); r.Err != nil || r.Code != 0 || r.Out != "ONE TWO\n" {
+		t.Error("Unexpected response", r.Out, r.Code, r.Err)
+	}
+	c = Command{Cmd: "realize-missing-command"}
+	if r := c.exec(".", nil, nil, nil); r.Err == nil || r.Code != -1 {
+		t.Error("Expected a start error", r.Code, r.Err)
+	}
+}
+
+func TestCommand_Timeout(t *testing.T) {
+	c := Command{Cmd: "sleep 5; echo done", Shell: "true", Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	r := c.exec(".", nil, nil, nil)
+	if r.Err == nil || r.Code != -1 || !strings.HasPrefix(r.Err.Error(), "timeout after 100ms") {
+		t.Error("Expected a timeout", r.Code, r.Err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("The children of a timed out command weren't killed")
+	}
+}
+
+func TestCommand_Retries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "retries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "count")
+	c := Command{Cmd: "echo x >> " + file + "; test $(wc -l < " + file + ") -ge 3", Shell: "true", Retries: 2}
+	if r := c.run(".", nil, nil, nil); r.Err != nil {
+		t.Error("Expected a success at the third attempt", r.Err)
+	}
+	c.Retries = 0
+	os.Remove(file)
+	if r := c.run(".", nil, nil, nil); r.Err == nil {
+		t.Error("Expected a failure without retries")
+	}
+}
+
+func TestProject_CmdFailure(t *testing.T) {
+	p := Project{parent: &Realize{}, Buffer: newBuffer(BufferSettings{}), state: &state{}}
+	p.Watcher.Scripts = []Command{
+		{Type: "before", Cmd: "false", OnFailure: FailureSkipRun},
+		{Type: "before", Cmd: "true"},
+	}
+	if failure := p.cmd(make(chan bool), "before", false, nil, nil); failure != FailureSkipRun {
+		t.Error("Expected skip-run, got", failure)
+	}
+	p.Watcher.Scripts = []Command{
+		{Type: "before", Cmd: "false", OnFailure: FailureAbort},
+		{Type: "before", Cmd: "realize-missing-command", OnFailure: FailureSkipRun},
+	}
+	if failure := p.cmd(make(chan bool), "before", false, nil, nil); failure != FailureAbort {
+		t.Error("Expected abort, got", failure)
+	}
+	p.Watcher.Scripts = []Command{{Type: "before", Cmd: "false"}}
+	if failure := p.cmd(make(chan bool), "before", false, nil, nil); failure != "" {
+		t.Error("Expected continue, got", failure)
+	}
+}
+
+func TestProject_GlobalFailure(t *testing.T) {
+	p := Project{Name: "global", Path: ".", parent: &Realize{}, Buffer: newBuffer(BufferSettings{}), state: &state{}}
+	p.Tools.Install = Tool{Status: true, Method: "true", name: "Install"}
+	p.Watcher.Scripts = []Command{{Type: "before", Cmd: "false", Global: true, OnFailure: FailureAbort}}
+	p.Tools.Setup()
+	p.global = p.cmd(make(chan bool), "before", true, nil, nil)
+	p.reload(nil, make(chan bool), p.global)
+	if logs := fmt.Sprint(p.Buffer.StdErr.Items()); !strings.Contains(logs, "Reload aborted") {
+		t.Error("Expected the first reload aborted", logs)
+	}
+	if logs := fmt.Sprint(p.Buffer.StdLog.Items()); strings.Contains(logs, "Install") {
+		t.Error("Unexpected build after an aborted reload", logs)
+	}
+}
+
+func TestCommand_ExecLines(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	streamed := make(chan bool, 1)
+	c := Command{Cmd: "echo one; echo two >&2; sleep 1; printf three", Shell: "true"}
+	done := make(chan Response)
+	go func() {
+		done <- c.exec(".", nil, nil, func(stream, text string) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, stream+" "+text)
+			if len(lines) == 2 {
+				streamed <- true
+			}
+		})
+	}()
+	select {
+	case <-streamed:
+	case <-done:
+		t.Fatal("Expected the lines before the end of the command")
+	}
+	r := <-done
+	if r.Err != nil || r.Out != "one\nthree" {
+		t.Error("Unexpected response", r.Out, r.Err)
+	}
+	sort.Strings(lines)
+	if !reflect.DeepEqual(lines, []string{"stderr two", "stdout one", "stdout three"}) {
+		t.Error("Unexpected lines", lines)
+	}
+}
+
+func TestProject_Background(t *testing.T) {
+	p := Project{Name: "background", parent: &Realize{}, Buffer: newBuffer(BufferSettings{}), state: &state{}}
+	p.Watcher.Scripts = []Command{
+		{Type: "before", Cmd: "echo started; sleep 10", Shell: "true", Background: true},
+		{Type: "before", Cmd: "realize-missing-command"},
+	}
+	if failure := p.cmd(make(chan bool), "before", false, nil, nil); failure != "" {
+		t.Error("Unexpected failure", failure)
+	}
+	if len(p.Buffer.StdErr.Items()) != 1 {
+		t.Error("Expected only the foreground command to run")
+	}
+	stop := p.background()
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(fmt.Sprint(p.Buffer.StdLog.Items()), "started") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(fmt.Sprint(p.Buffer.StdLog.Items()), "started") {
+		t.Error("Expected the output of the background script")
+	}
+	start := time.Now()
+	stop()
+	if time.Since(start) > 2*time.Second {
+		t.Error("The background script wasn't killed")
+	}
+}
+
+func TestProject_CmdOn(t *testing.T) {
+	p := Project{Name: "on", Path: ".", parent: &Realize{}, Buffer: newBuffer(BufferSettings{}), state: &state{}, writes: &writes{}}
+	p.Watcher.Scripts = []Command{
+		{Type: "before", Cmd: "echo proto", On: []string{"*.proto"}},
+		{Type: "before", Cmd: "echo always"},
+	}
+	p.cmd(make(chan bool), "before", false, nil, nil)
+	if logs := fmt.Sprint(p.Buffer.StdLog.Items()); strings.Contains(logs, "proto") || !strings.Contains(logs, "always") {
+		t.Error("Expected only the script without patterns", logs)
+	}
+	p.cmd(make(chan bool), "before", false, nil, []string{"api/service.proto"})
+	if logs := fmt.Sprint(p.Buffer.StdLog.Items()); !strings.Contains(logs, "proto") {
+		t.Error("Expected the triggered script", logs)
+	}
+	p.writes.record("api/service.pb.go")
+	if paths := p.writes.triggers([]string{"api/service.pb.go"}); len(paths) != 0 {
+		t.Error("Expected the output of a triggered script to not trigger it again", paths)
+	}
+}
+
+func TestProject_CmdEnv(t *testing.T) {
+	p := Project{Name: "env", Path: ".", parent: &Realize{}, Buffer: newBuffer(BufferSettings{}), state: &state{}}
+	p.Env = map[string]string{"REALIZE_TEST_SCRIPT": "project", "REALIZE_TEST_KEEP": "kept"}
+	p.Watcher.Scripts = []Command{
+		{Type: "before", Cmd: "echo $REALIZE_TEST_SCRIPT $REALIZE_TEST_KEEP", Shell: "true", Env: map[string]string{"REALIZE_TEST_SCRIPT": "command"}},
+	}
+	p.cmd(make(chan bool), "before", false, nil, nil)
+	if logs := fmt.Sprint(p.Buffer.StdLog.Items()); !strings.Contains(logs, "command kept") {
+		t.Error("Expected the command env over the project env", logs)
+	}
+}
+
+func TestProject_CmdExpanded(t *testing.T) {
+	p := Project{Name: "expanded", Path: ".", parent: &Realize{}, Buffer: newBuffer(BufferSettings{}), state: &state{}}
+	p.Env = map[string]string{"REALIZE_TEST_SECRET": "hidden"}
+	p.Watcher.Scripts = []Command{{Type: "before", Cmd: "echo ${REALIZE_TEST_SECRET}"}}
+	if err := p.environment(); err != nil {
+		t.Fatal(err)
+	}
+	p.cmd(make(chan bool), "before", false, nil, nil)
+	logs := p.Buffer.StdLog.Items()
+	if len(logs) != 2 || logs[0].Text != "hidden" || logs[1].Text != "echo ${REALIZE_TEST_SECRET} completed" {
+		t.Error("Expected the expanded command run and the raw one shown", logs)
+	}
+	// a script added after the expansion
+	p.Watcher.Scripts = append(p.Watcher.Scripts, Command{Type: "before", Cmd: "echo ${REALIZE_TEST_SECRET} added"})
+	p.cmd(make(chan bool), "before", false, nil, nil)
+	if logs := fmt.Sprint(p.Buffer.StdLog.Items()); !strings.Contains(logs, "hidden added") {
+		t.Error("Expected the added script expanded", logs)
+	}
+}
@@ -0,0 +1,97 @@
+package realize
+
+import (
+	"sync"
+	"time"
+)
+
+// project states
+const (
+	StateIdle     = "idle"
+	StateWaiting  = "waiting"
+	StateBuilding = "building"
+	StateRunning  = "running"
+	StateFailed   = "failed"
+	StateExited   = "exited"
+	StateStopped  = "stopped"
+)
+
+// project control commands
+const (
+	ControlRebuild = "rebuild"
+	ControlStop    = "stop"
+	ControlStart   = "start"
+)
+
+// BuildResult is the result of the last install or build of a project
+type BuildResult struct {
+	Tool        string        `json:"tool"`
+	Time        time.Time     `json:"time"`
+	Duration    time.Duration `json:"duration"`
+	Success     bool          `json:"success"`
+	Errors      []string      `json:"errors,omitempty"`
+	Diagnostics []Diagnostic  `json:"diagnostics,omitempty"`
+}
+
+// ProjectStatus is the current status of a project
+type ProjectStatus struct {
+	Name      string       `json:"name"`
+	Path      string       `json:"path"`
+	State     string       `json:"state"`
+	Since     time.Time    `json:"since"`
+	LastBuild *BuildResult `json:"last_build,omitempty"`
+}
+
+// State is shared between the watcher of a project and its readers
+type state struct {
+	mu    sync.RWMutex
+	name  string
+	since time.Time
+	build *BuildResult
+}
+
+// set the current state
+func (s *state) set(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+	s.since = time.Now()
+}
+
+// built records the result of an install or a build
+func (s *state) built(start time.Time, r Response) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.build = &BuildResult{
+		Tool:        r.Name,
+		Time:        start,
+		Duration:    time.Since(start),
+		Success:     r.Err == nil,
+		Diagnostics: r.Diagnostics,
+	}
+	if r.Err != nil {
+		s.build.Errors = []string{r.Err.Error()}
+	}
+}
+
+// Status returns the current status of the project
+func (p *Project) Status() ProjectStatus {
+	status := ProjectStatus{Name: p.Name, Path: p.Path, State: StateIdle}
+	if p.state == nil {
+		return status
+	}
+	p.state.mu.RLock()
+	defer p.state.mu.RUnlock()
+	if p.state.name != "" {
+		status.State = p.state.name
+	}
+	status.Since = p.state.since
+	status.LastBuild = p.state.build
+	return status
+}
@@ -7,23 +7,34 @@ import (
 	"log"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"time"
 )
 
 // Tool info
 type Tool struct {
-	Args   []string `yaml:"args,omitempty" json:"args,omitempty"`
-	Method string   `yaml:"method,omitempty" json:"method,omitempty"`
-	Path   string   `yaml:"path,omitempty" json:"path,omitempty"`
-	Dir    string   `yaml:"dir,omitempty" json:"dir,omitempty"` //wdir of the command
-	Status bool     `yaml:"status,omitempty" json:"status,omitempty"`
-	Output bool     `yaml:"output,omitempty" json:"output,omitempty"`
-	dir    bool
-	isTool bool
-	method []string
-	cmd    []string
-	name   string
-	parent *Project
+	Args        []string          `yaml:"args,omitempty" json:"args,omitempty"`
+	Method      string            `yaml:"method,omitempty" json:"method,omitempty"`
+	Path        string            `yaml:"path,omitempty" json:"path,omitempty"`
+	Dir         string            `yaml:"dir,omitempty" json:"dir,omitempty"` //wdir of the command
+	Status      bool              `yaml:"status,omitempty" json:"status,omitempty"`
+	Output      bool              `yaml:"output,omitempty" json:"output,omitempty"`
+	Scope       string            `yaml:"scope,omitempty" json:"scope,omitempty"`
+	StopSignal  string            `yaml:"stop_signal,omitempty" json:"stop_signal,omitempty"`
+	StopTimeout time.Duration     `yaml:"stop_timeout,omitempty" json:"stop_timeout,omitempty"`
+	Restart     string            `yaml:"restart,omitempty" json:"restart,omitempty"`
+	MaxRetries  int               `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
+	Backoff     time.Duration     `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	Healthcheck *Healthcheck      `yaml:"healthcheck,omitempty" json:"healthcheck,omitempty"`
+	Env         map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	dir         bool
+	json        bool
+	isTool      bool
+	method      []string
+	cmd         []string
+	name        string
+	parent      *Project
 }
 
 // Tools go
@@ -39,6 +50,11 @@ type Tools struct {
 	vgo      bool
 }
 
+// list returns all the tools
+func (t *Tools) list() []*Tool {
+	return []*Tool{&t.Clean, &t.Vet, &t.Fmt, &t.Test, &t.Generate, &t.Install, &t.Build, &t.Run}
+}
+
 // Setup go tools
 func (t *Tools) Setup() {
 	var gocmd string
@@ -88,6 +104,8 @@ func (t *Tools) Setup() {
 		t.Test.name = "Test"
 		t.Test.cmd = replace([]string{gocmd, "test"}, t.Test.Method)
 		t.Test.Args = split([]string{}, t.Test.Args)
+		// structured results are available only with the go test command
+		t.Test.json = t.Test.Method == ""
 	}
 	// go install
 	t.Install.name = "Install"
@@ -101,8 +119,31 @@ func (t *Tools) Setup() {
 	}
 }
 
-// Exec a go tool
-func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
+// Golist returns the command listing the packages, with the go command of a custom test method
+func (t *Tools) golist() []string {
+	if fields := strings.Fields(t.Test.Method); len(fields) > 1 && fields[len(fields)-1] == "test" {
+		return append(fields[:len(fields)-1:len(fields)-1], "list")
+	}
+	if t.vgo {
+		return []string{"vgo", "list"}
+	}
+	return []string{"go", "list"}
+}
+
+// Scoped check if an enabled per package tool runs on the affected packages
+func (t *Tools) scoped() bool {
+	v := reflect.ValueOf(*t)
+	for i := 0; i < v.NumField()-1; i++ {
+		tool := v.Field(i).Interface().(Tool)
+		if tool.Status && tool.dir && tool.Scope == ScopeAffected {
+			return true
+		}
+	}
+	return false
+}
+
+// Exec a go tool with the env if not nil
+func (t *Tool) Exec(path string, env []string, stop <-chan bool) (response Response) {
 	if t.dir {
 		if filepath.Ext(path) != "" {
 			path = filepath.Dir(path)
@@ -122,7 +163,10 @@ func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
 	} else if !strings.HasSuffix(path, ".go") {
 		return
 	}
-	args := t.Args
+	args := append([]string{}, t.Args...)
+	if t.json {
+		args = append([]string{"-json"}, args...)
+	}
 	if strings.HasSuffix(path, ".go") {
 		args = append(args, path)
 		path = filepath.Dir(path)
@@ -135,6 +179,7 @@ func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
 		done := make(chan error)
 		args = append(t.cmd, args...)
 		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Env = env
 		if t.Dir != "" {
 			cmd.Dir, _ = filepath.Abs(t.Dir)
 		} else {
@@ -158,11 +203,23 @@ func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
 		case err := <-done:
 			// Command completed
 			response.Name = t.name
+			text := out.String()
+			failures := text
+			if t.json {
+				response.Tests, text = parseTests(&out)
+				if len(response.Tests) > 0 {
+					failures = testFailures(response.Tests)
+				} else {
+					failures = text
+				}
+			}
 			if err != nil {
-				response.Err = errors.New(stderr.String() + out.String() + err.Error())
+				dir, _ := filepath.Abs(cmd.Dir)
+				response.Err = errors.New(stderr.String() + failures + err.Error())
+				response.Diagnostics = diagnostics(t.name, dir, stderr.String()+text)
 			} else {
 				if t.Output {
-					response.Out = out.String()
+					response.Out = text
 				}
 			}
 		}
@@ -170,13 +227,14 @@ func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
 	return
 }
 
-// Compile is used for build and install
-func (t *Tool) Compile(path string, stop <-chan bool) (response Response) {
+// Compile is used for build and install, with the env if not nil
+func (t *Tool) Compile(path string, env []string, stop <-chan bool) (response Response) {
 	var out bytes.Buffer
 	var stderr bytes.Buffer
 	done := make(chan error)
 	args := append(t.cmd, t.Args...)
 	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = env
 	if t.Dir != "" {
 		cmd.Dir, _ = filepath.Abs(t.Dir)
 	} else {
@@ -196,7 +254,9 @@ func (t *Tool) Compile(path string, stop <-chan bool) (response Response) {
 	case err := <-done:
 		// Command completed
 		if err != nil {
+			dir, _ := filepath.Abs(cmd.Dir)
 			response.Err = errors.New(stderr.String() + err.Error())
+			response.Diagnostics = diagnostics(t.name, dir, stderr.String())
 		}
 	}
 	return
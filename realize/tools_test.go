@@ -1,6 +1,9 @@
 package realize
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestTools_Setup(t *testing.T) {
 	tools := Tools{
@@ -23,3 +26,34 @@ func TestTools_Setup(t *testing.T) {
 		t.Error("Unexpected value")
 	}
 }
+
+func TestTools_Scoped(t *testing.T) {
+	tools := Tools{
+		Test: Tool{
+			Status: true,
+			Scope:  ScopeAffected,
+		},
+	}
+	if tools.scoped() {
+		t.Error("Unexpected scoped tools before setup")
+	}
+	tools.Setup()
+	if !tools.scoped() {
+		t.Error("Expected scoped tools")
+	}
+}
+
+func TestTools_Golist(t *testing.T) {
+	data := map[string]Tools{
+		"go list":             {},
+		"vgo list":            {vgo: true},
+		"/opt/go/bin/go list": {Test: Tool{Method: "/opt/go/bin/go test"}},
+		"go1.14 list":         {vgo: true, Test: Tool{Method: "go1.14 test"}},
+	}
+	for expected, tools := range data {
+		tools.Setup()
+		if result := strings.Join(tools.golist(), " "); result != expected {
+			t.Error("Unexpected list command", result, "expected", expected)
+		}
+	}
+}
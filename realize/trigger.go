@@ -0,0 +1,99 @@
+package realize
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// triggered checks if a changed path matches the on patterns of a command,
+// a pattern without a slash matches the file name, as *.proto, otherwise the path relative to the project
+func (c *Command) triggered(rels []string) bool {
+	for _, pattern := range c.On {
+		pattern = strings.TrimPrefix(pattern, "/")
+		for _, rel := range rels {
+			if !strings.Contains(pattern, "/") {
+				if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+					return true
+				}
+			} else if glob(pattern, rel) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writes tracks the running triggered scripts, the paths changed meanwhile are their own output,
+// they reload the project once but don't trigger the scripts again
+type writes struct {
+	mu      sync.Mutex
+	running int
+	until   time.Time
+	paths   map[string]bool
+}
+
+func (w *writes) begin() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running++
+}
+
+// end of a script, the late events are its output for a grace period
+func (w *writes) end(grace time.Duration) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running--
+	if until := time.Now().Add(grace); until.After(w.until) {
+		w.until = until
+	}
+}
+
+// busy checks if a triggered script is running, the reload waits its end
+func (w *writes) busy() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.running > 0
+}
+
+// record a changed path, kept if written by a triggered script
+func (w *writes) record(path string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running > 0 || time.Now().Before(w.until) {
+		if w.paths == nil {
+			w.paths = make(map[string]bool)
+		}
+		w.paths[path] = true
+	}
+}
+
+// triggers returns the changed paths without the ones written by the triggered scripts
+func (w *writes) triggers(paths []string) []string {
+	if w == nil || len(paths) == 0 {
+		return paths
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var result []string
+	for _, path := range paths {
+		if !w.paths[path] {
+			result = append(result, path)
+		}
+	}
+	w.paths = nil
+	return result
+}
@@ -0,0 +1,58 @@
+package realize
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand_Triggered(t *testing.T) {
+	c := Command{On: []string{"*.proto", "/migrations/*.sql"}}
+	cases := map[string]bool{
+		"api/v1/service.proto":  true,
+		"service.proto":         true,
+		"migrations/001.sql":    true,
+		"db/migrations/001.sql": false,
+		"main.go":               false,
+	}
+	for rel, expected := range cases {
+		if c.triggered([]string{rel}) != expected {
+			t.Error("Unexpected match of", rel)
+		}
+	}
+	if c.triggered(nil) {
+		t.Error("Expected no match without changes")
+	}
+}
+
+func TestWrites(t *testing.T) {
+	var none *writes
+	none.begin()
+	none.record("gen.go")
+	none.end(time.Second)
+	if none.busy() || len(none.triggers([]string{"gen.go"})) != 1 {
+		t.Error("Unexpected writes without tracking")
+	}
+	w := &writes{}
+	w.record("main.go")
+	w.begin()
+	if !w.busy() {
+		t.Error("Expected a busy script")
+	}
+	w.record("gen.go")
+	w.end(50 * time.Millisecond)
+	w.record("late.go")
+	if w.busy() {
+		t.Error("Unexpected busy script after its end")
+	}
+	time.Sleep(100 * time.Millisecond)
+	w.record("user.go")
+	paths := w.triggers([]string{"main.go", "gen.go", "late.go", "user.go"})
+	if strings.Join(paths, ",") != "main.go,user.go" {
+		t.Error("Expected only the paths not written by the script", paths)
+	}
+	// the written paths are excluded once
+	if paths := w.triggers([]string{"gen.go"}); len(paths) != 1 {
+		t.Error("Expected the path to trigger again", paths)
+	}
+}
@@ -84,3 +84,39 @@ func hasGoMod(dir string) bool {
 
 	return true
 }
+
+// isGlob check if a string contains any pattern meta character
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// Glob check if a slash separated path matches a pattern, "**" matches any number of folders
+func glob(pattern, name string) bool {
+	return globSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func globSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				// a trailing "**" matches everything inside
+				return len(name) > 0
+			}
+			for i := range name {
+				if globSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
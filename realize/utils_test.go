@@ -71,3 +71,24 @@ func TestExt(t *testing.T) {
 	}
 
 }
+
+func TestGlob(t *testing.T) {
+	data := map[[2]string]bool{
+		{"*.go", "main.go"}:                       true,
+		{"*.go", "a/main.go"}:                     false,
+		{"**/*.go", "main.go"}:                    true,
+		{"**/*.go", "a/b/main.go"}:                true,
+		{"internal/**/*.go", "internal/a/b.go"}:   true,
+		{"internal/**/*.go", "internal/b.go"}:     true,
+		{"internal/**/*.go", "cmd/internal/b.go"}: false,
+		{"build/**", "build/a/b"}:                 true,
+		{"build/**", "build"}:                     false,
+		{"a/?.go", "a/b.go"}:                      true,
+		{"[", "["}:                                false,
+	}
+	for v, expected := range data {
+		if glob(v[0], v[1]) != expected {
+			t.Error("Unexpected result", v[0], v[1], "expected", expected)
+		}
+	}
+}
@@ -4,6 +4,12 @@ package realize
 
 import "strings"
 
+// defaultShell runs the scripts with shell: true
+const defaultShell = "sh"
+
+// backslashEscape is on, a backslash escapes the next character of the scripts
+const backslashEscape = true
+
 // isHidden check if a file or a path is hidden
 func isHidden(path string) bool {
 	arr := strings.Split(path[len(Wdir()):], "/")
@@ -4,6 +4,12 @@ package realize
 
 import "syscall"
 
+// defaultShell runs the scripts with shell: true
+const defaultShell = "cmd"
+
+// backslashEscape is off, the backslashes of the scripts are path separators
+const backslashEscape = false
+
 // isHidden check if a file or a path is hidden
 func isHidden(path string) bool {
 	p, e := syscall.UTF16PtrFromString(path)
@@ -0,0 +1,253 @@
+package realize
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// line of the yaml errors
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// Problem is an error of a config file at a line, zero if unknown
+type Problem struct {
+	Line    int
+	Message string
+}
+
+// Problems is the list of the errors of a config file
+type Problems []Problem
+
+func (p Problem) Error() string {
+	if p.Line > 0 {
+		return "line " + strconv.Itoa(p.Line) + ": " + p.Message
+	}
+	return p.Message
+}
+
+func (p Problems) Error() string {
+	var list []string
+	for _, v := range p {
+		list = append(list, v.Error())
+	}
+	return strings.Join(list, "\n")
+}
+
+// Validate checks a config file, the paths are relative to the working directory
+func Validate(content []byte) Problems {
+	var problems Problems
+	var r Realize
+	if err := yaml.UnmarshalStrict(content, &r); err != nil {
+		errs := []string{err.Error()}
+		terr, ok := err.(*yaml.TypeError)
+		if ok {
+			errs = terr.Errors
+		}
+		for _, e := range errs {
+			problems = append(problems, problem(e))
+		}
+		// a syntax error stops the decoding
+		if !ok {
+			return problems
+		}
+	}
+	lines := keyLines(content)
+	add := func(key string, format string, args ...interface{}) {
+		problems = append(problems, Problem{Line: lines.find(key), Message: fmt.Sprintf(format, args...)})
+	}
+	names := make(map[string]bool)
+	for i, p := range r.Schema.Projects {
+		key := "schema." + strconv.Itoa(i)
+		if _, err := duplicates(p, r.Schema.Projects[:i]); err != nil {
+			add(key+".name", "duplicated project name %q", p.Name)
+		}
+		names[p.Name] = true
+		if p.Path != "" {
+			if _, err := os.Stat(p.dir()); err != nil {
+				add(key+".path", "path %q of project %q doesn't exist", p.Path, p.Name)
+			}
+		}
+		for j, c := range p.Watcher.Scripts {
+			script := key + ".watcher.scripts." + strconv.Itoa(j)
+			switch c.OnFailure {
+			case "", FailureContinue, FailureAbort, FailureSkipRun:
+			default:
+				add(script+".on_failure", "invalid on_failure %q, expected continue, abort or skip-run", c.OnFailure)
+			}
+			if _, err := c.args(); err != nil {
+				add(script+".command", "invalid command %q of script: %s", c.Cmd, err.Error())
+			}
+			if len(c.On) > 0 && (c.Global || c.Background) {
+				add(script+".on", "on patterns of script %q are only for the scripts run on change, not global or background", c.Cmd)
+			}
+			for _, pattern := range c.On {
+				if _, err := path.Match(pattern, ""); err != nil {
+					add(script+".on", "invalid on pattern %q of script %q", pattern, c.Cmd)
+				}
+			}
+		}
+		if h := p.Tools.Run.Healthcheck; h != nil && h.Command != "" {
+			if _, err := h.args(); err != nil {
+				add(key+".commands.run.healthcheck.command", "invalid healthcheck command of project %q: %s", p.Name, err.Error())
+			}
+		}
+		if p.ErrPattern != "" {
+			if _, err := regexp.Compile(p.ErrPattern); err != nil {
+				add(key+".pattern", "invalid pattern of project %q: %s", p.Name, err.Error())
+			}
+		}
+	}
+	unknown := false
+	for i, p := range r.Schema.Projects {
+		for _, name := range p.DependsOn {
+			if !names[name] {
+				unknown = true
+				add("schema."+strconv.Itoa(i)+".depends_on", "project %q depends on the unknown project %q", p.Name, name)
+			}
+		}
+	}
+	if !unknown {
+		if _, err := r.Schema.Order(); err != nil {
+			add("schema", err.Error())
+		}
+	}
+	durations(reflect.ValueOf(&r), "", func(key string) {
+		add(key, "negative duration %s", strings.TrimPrefix(key[strings.LastIndex(key, "."):], "."))
+	})
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems
+}
+
+// problem parses the line of a yaml error
+func problem(err string) Problem {
+	if match := yamlLine.FindStringSubmatch(err); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return Problem{Line: line, Message: match[2]}
+	}
+	return Problem{Message: strings.TrimPrefix(err, "yaml: ")}
+}
+
+// durations calls fn with the yaml key of each negative duration
+func durations(v reflect.Value, key string, fn func(key string)) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			durations(v.Elem(), key, fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			durations(v.Index(i), join(key, strconv.Itoa(i)), fn)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			durations(v.MapIndex(k), join(key, fmt.Sprint(k.Interface())), fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("yaml"), ",")
+			name := tag[0]
+			switch {
+			case name == "-":
+				continue
+			case len(tag) > 1 && tag[1] == "inline":
+				name = ""
+			case name == "":
+				name = strings.ToLower(field.Name)
+			}
+			durations(v.Field(i), join(key, name), fn)
+		}
+	case reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) && v.Int() < 0 {
+			fn(key)
+		}
+	}
+}
+
+func join(key, name string) string {
+	if key == "" || name == "" {
+		return key + name
+	}
+	return key + "." + name
+}
+
+// lines of the keys of a yaml document
+type lines map[string]int
+
+// find returns the line of a key or of its nearest parent
+func (l lines) find(key string) int {
+	for key != "" {
+		if line, ok := l[key]; ok {
+			return line
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return 0
+}
+
+// keyLines indexes the keys of a block yaml document, the items of a list by their index, as schema.0.path
+func keyLines(content []byte) lines {
+	type frame struct {
+		indent int
+		key    string
+		item   bool
+	}
+	result := make(lines)
+	items := make(map[string]int)
+	var stack []frame
+	for n, text := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		indent := len(text) - len(trimmed)
+		item := strings.HasPrefix(trimmed, "- ") || trimmed == "-"
+		// a list can be at the same indentation of its key
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.indent < indent || top.indent == indent && item && !top.item {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		parent := ""
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].key
+		}
+		if item {
+			key := join(parent, strconv.Itoa(items[parent]))
+			items[parent]++
+			result[key] = n + 1
+			stack = append(stack, frame{indent: indent, key: key, item: true})
+			parent = key
+			trimmed = strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+			indent = len(text) - len(trimmed)
+		}
+		i := strings.Index(trimmed, ":")
+		if i <= 0 || i+1 < len(trimmed) && trimmed[i+1] != ' ' || strings.HasPrefix(trimmed, "{") {
+			continue
+		}
+		key := join(parent, strings.Trim(trimmed[:i], `"'`))
+		result[key] = n + 1
+		stack = append(stack, frame{indent: indent, key: key})
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package realize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	config := `settings:
+  legacy:
+    interval: -1s
+schema:
+- name: app
+  path: .
+  comands:
+    install:
+      status: true
+- name: app
+  path: missing
+  pattern: "[a-"
+  watcher:
+    debounce: 1x
+- name: gateway
+  depends_on: [auth]
+`
+	problems := Validate([]byte(config))
+	expected := map[int]string{
+		3:  "negative duration interval",
+		7:  "field comands not found",
+		10: "duplicated project name",
+		11: "doesn't exist",
+		12: "invalid pattern",
+		14: "cannot unmarshal",
+		16: "unknown project",
+	}
+	if len(problems) != len(expected) {
+		t.Fatal("Unexpected problems", problems)
+	}
+	for _, p := range problems {
+		if expected[p.Line] == "" || !strings.Contains(p.Message, expected[p.Line]) {
+			t.Error("Unexpected problem", p)
+		}
+	}
+	if !strings.HasPrefix(problems.Error(), "line 3: ") {
+		t.Error("Unexpected error", problems.Error())
+	}
+}
+
+func TestValidate_Syntax(t *testing.T) {
+	problems := Validate([]byte("schema:\n- name: app\n  path: [.\n"))
+	if len(problems) != 1 || problems[0].Line == 0 {
+		t.Error("Expected a syntax error with its line", problems)
+	}
+	problems = Validate([]byte("schema:\n- name: a\n  depends_on: [b]\n- name: b\n  depends_on: [a]\n"))
+	if len(problems) != 1 || problems[0].Line != 1 || !strings.Contains(problems[0].Message, "cycle") {
+		t.Error("Expected a dependency cycle", problems)
+	}
+	if problems := Validate([]byte("schema:\n- name: app\n  path: .\n")); len(problems) != 0 {
+		t.Error("Unexpected problems", problems)
+	}
+}
+
+func TestKeyLines(t *testing.T) {
+	lines := keyLines([]byte("# comment\nschema:\n- name: a\n  watcher:\n    paths:\n    - /\n- name: b\n  path: b\n"))
+	for key, line := range map[string]int{"schema": 2, "schema.0.name": 3, "schema.0.watcher.paths.0": 6, "schema.1.path": 8} {
+		if lines[key] != line {
+			t.Error("Unexpected line of", key, lines[key])
+		}
+	}
+	if lines.find("schema.1.commands.run") != 7 {
+		t.Error("Expected the line of the nearest parent", lines.find("schema.1.commands.run"))
+	}
+}
+
+func TestValidate_Scripts(t *testing.T) {
+	config := `schema:
+- name: app
+  path: .
+  watcher:
+    scripts:
+    - type: before
+      command: buf generate
+      on: ["*.proto", "[a-"]
+      on_failure: stop
+    - type: before
+      command: echo
+      global: true
+      on: ["*.go"]
+    - type: after
+      command: echo "unterminated
+    - type: after
+      command: echo "shell
+      shell: "true"
+  commands:
+    run:
+      healthcheck:
+        command: "  "
+`
+	problems := Validate([]byte(config))
+	expected := map[int]string{
+		8:  "invalid on pattern",
+		9:  "invalid on_failure",
+		13: "only for the scripts run on change",
+		15: "invalid command",
+		22: "invalid healthcheck command",
+	}
+	if len(problems) != len(expected) {
+		t.Fatal("Unexpected problems", problems)
+	}
+	for _, p := range problems {
+		if expected[p.Line] == "" || !strings.Contains(p.Message, expected[p.Line]) {
+			t.Error("Unexpected problem", p)
+		}
+	}
+}